@@ -0,0 +1,268 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"log"
+	"math/rand"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+// this file contains an alternative to the backtracking search in route.go,
+// based on "assumed fill": each item is placed in a random slot that's
+// reachable if you assume you already have every item that hasn't been placed
+// yet. as long as there's somewhere to put each item, the result is beatable,
+// so there's no need to search. the catch is that the goal, forbid, maxlen,
+// and softlock conditions can only be checked after the fact, so the whole
+// thing is retried a limited number of times until they're satisfied.
+
+// number of times to try filling the slots before giving up
+const maxAssumedFillTries = 1000
+
+// attempts to create a path to the given targets by placing items via assumed
+// fill. like findRoute, all randomness comes from src. unlike findRoute, it
+// returns an error instead of searching indefinitely.
+func findAssumedRoute(src *rand.Rand, r *Route, start, goal, forbid []string,
+	maxlen int) (usedItems, usedSlots *list.List, err error) {
+	if err := checkNodeNames(r.Graph, goal); err != nil {
+		return nil, nil, err
+	}
+	if err := checkNodeNames(r.Graph, forbid); err != nil {
+		return nil, nil, err
+	}
+
+	startNodes := make([]*graph.Node, len(start))
+	for i, name := range start {
+		startNodes[i] = r.Graph[name]
+	}
+
+	for try := 0; try < maxAssumedFillTries; try++ {
+		itemList, slotList := initRouteLists(src, r)
+		usedItems, usedSlots = list.New(), list.New()
+		err = placePinnedItems(r, itemList, usedItems, slotList, usedSlots)
+		if err == nil {
+			err = placeDungeonItems(r.Graph, startNodes,
+				itemList, usedItems, slotList, usedSlots)
+		}
+
+		// like in findRoute, items placed ahead of time don't count toward
+		// maxlen
+		placedBefore := usedItems.Len()
+		if err == nil {
+			err = tryAssumedFill(src, r.Graph, startNodes, goal,
+				listNodes(itemList), listNodes(slotList), usedItems, usedSlots)
+		}
+		if err == nil {
+			err = checkAssumedRoute(r.Graph, startNodes, goal, forbid, maxlen,
+				listNodes(usedItems)[placedBefore:])
+		}
+		if err == nil {
+			log.Print("-- success")
+			announceSuccessDetails(r, goal, usedItems, usedSlots)
+			return usedItems, usedSlots, nil
+		}
+		log.Printf("-- assumed fill try %d failed: %v", try+1, err)
+
+		// unslot everything and start over
+		for e := usedItems.Front(); e != nil; e = e.Next() {
+			e.Value.(*graph.Node).ClearParents()
+		}
+	}
+
+	return nil, nil, fmt.Errorf(
+		"could not find route in %d assumed fill tries", maxAssumedFillTries)
+}
+
+// place each item in a random slot that's reachable when assuming all the
+// items before it, preferring slots that need the most of them. if there are
+// more items than slots, items are left out first, as long as the goals are
+// still reachable when assuming everything else.
+func tryAssumedFill(src *rand.Rand, g graph.Graph, start []*graph.Node,
+	goal []string, items, slots []*graph.Node,
+	usedItems, usedSlots *list.List) error {
+	for i := 0; i < len(items) && len(items) > len(slots); {
+		others := append(append([]*graph.Node{}, items[:i]...), items[i+1:]...)
+		if reachesAll(g.Explore(make(map[*graph.Node]bool),
+			append(others, start...)), g, goal) {
+			items = others
+		} else {
+			i++
+		}
+	}
+	if len(items) > len(slots) {
+		return fmt.Errorf("%d items don't fit in %d slots",
+			len(items), len(slots))
+	}
+
+	open := make(map[*graph.Node]bool, len(slots))
+	for _, slot := range slots {
+		open[slot] = true
+	}
+
+	for len(items) > 0 {
+		// take the last item and assume all the others
+		item := items[len(items)-1]
+		items = items[:len(items)-1]
+		levels := assumedLevels(start, items)
+
+		// slots that need more items to reach are the first to go out of
+		// reach as items are placed, so pick among the ones that need the
+		// most. the last items can only go in slots that need none.
+		candidates := make([]*graph.Node, 0)
+		maxLevel := 0
+		for _, node := range slots {
+			level, ok := levels[node]
+			if !open[node] || !ok || level < maxLevel {
+				continue
+			}
+			if skip, _ := shouldSkipItem(item, node, false); skip {
+				continue
+			}
+			if level > maxLevel {
+				candidates, maxLevel = candidates[:0], level
+			}
+			candidates = append(candidates, node)
+		}
+		if len(candidates) == 0 {
+			return fmt.Errorf("no reachable slot for %s", item)
+		}
+		slot := candidates[src.Intn(len(candidates))]
+
+		item.AddParents(slot)
+		usedItems.PushBack(item)
+		usedSlots.PushBack(slot)
+		delete(open, slot)
+	}
+
+	return nil
+}
+
+// returns the number of items from the front of the list that need to be
+// assumed to reach each node, for every node that's reachable when assuming
+// all of them. nodes are reached in the same way as in graph.Explore.
+func assumedLevels(start, items []*graph.Node) map[*graph.Node]int {
+	levels := make(map[*graph.Node]int)
+	counts := make(map[*graph.Node]int) // parents reached so far
+	buckets := make([][]*graph.Node, len(items)+1)
+	buckets[0] = append(buckets[0], start...)
+	for i, item := range items {
+		buckets[i+1] = append(buckets[i+1], item)
+	}
+
+	// nodes are reached in order of level, so a node's level is the level
+	// of the parent that satisfies it
+	for level := range buckets {
+		for i := 0; i < len(buckets[level]); i++ {
+			node := buckets[level][i]
+			if _, ok := levels[node]; ok {
+				continue
+			}
+			levels[node] = level
+
+			for _, child := range node.Children {
+				if _, ok := levels[child]; ok {
+					continue
+				}
+				counts[child]++
+				switch child.Type {
+				case graph.OrType:
+				case graph.AndType:
+					if counts[child] < len(child.Parents) {
+						continue
+					}
+				case graph.CountType:
+					if counts[child] < child.Threshold {
+						continue
+					}
+				default:
+					continue
+				}
+				buckets[level] = append(buckets[level], child)
+			}
+		}
+	}
+
+	return levels
+}
+
+// returns an error if the filled route doesn't satisfy all the conditions that
+// the backtracking search would check along the way. placed is the items
+// that count toward maxlen.
+func checkAssumedRoute(g graph.Graph, start []*graph.Node,
+	goal, forbid []string, maxlen int, placed []*graph.Node) error {
+	reached := g.Explore(make(map[*graph.Node]bool), start)
+	for _, name := range forbid {
+		if reached[g[name]] {
+			return fmt.Errorf("reached forbidden node %s", name)
+		}
+	}
+	for _, name := range goal {
+		if !reached[g[name]] {
+			return fmt.Errorf("did not reach goal node %s", name)
+		}
+	}
+
+	// softlock checks rely on the marks from exploring
+	if err := canSoftlock(g); err != nil {
+		return err
+	}
+
+	if maxlen >= 0 {
+		if n := countRouteItems(g, start, goal, placed); n > maxlen {
+			return fmt.Errorf("route needs %d items; maxlen is %d", n, maxlen)
+		}
+	}
+
+	return nil
+}
+
+// returns the number of the given items that are needed to reach the goals,
+// which is what maxlen limits in the backtracking search: it only slots items
+// that lead somewhere, and stops once the goals are reached. items are left
+// out one at a time as long as the goals can still be reached without them,
+// so alternatives (like two swords) count once instead of not at all.
+func countRouteItems(g graph.Graph, start []*graph.Node, goal []string,
+	items []*graph.Node) int {
+	parents := make([][]*graph.Node, len(items))
+	for i, item := range items {
+		parents[i] = append([]*graph.Node{}, item.Parents...)
+	}
+	defer func() {
+		for i, item := range items {
+			item.ClearParents()
+			item.AddParents(parents[i]...)
+		}
+	}()
+
+	n := 0
+	for i, item := range items {
+		item.ClearParents()
+		if !reachesAll(g.Explore(make(map[*graph.Node]bool), start),
+			g, goal) {
+			item.AddParents(parents[i]...)
+			n++
+		}
+	}
+	return n
+}
+
+// returns true iff all the named nodes are in the reached set
+func reachesAll(reached map[*graph.Node]bool, g graph.Graph,
+	names []string) bool {
+	for _, name := range names {
+		if !reached[g[name]] {
+			return false
+		}
+	}
+	return true
+}
+
+// return the nodes in the list as a slice, in the same order
+func listNodes(l *list.List) []*graph.Node {
+	nodes := make([]*graph.Node, 0, l.Len())
+	for e := l.Front(); e != nil; e = e.Next() {
+		nodes = append(nodes, e.Value.(*graph.Node))
+	}
+	return nodes
+}
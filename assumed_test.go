@@ -0,0 +1,68 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+func TestFindAssumedRoute(t *testing.T) {
+	start, goal := []string{"horon village"}, []string{"done"}
+	r := NewRoute(start)
+
+	usedItems, usedSlots, err := findAssumedRoute(
+		rand.New(rand.NewSource(5)), r, start, goal, []string{}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every slot should be filled, and the goal should be reachable
+	if usedSlots.Len() != len(r.Slots) || usedItems.Len() != len(r.Slots) {
+		t.Errorf("want %d slots filled, got %d", len(r.Slots), usedSlots.Len())
+	}
+	reached := r.Graph.Explore(
+		make(map[*graph.Node]bool), []*graph.Node{r.Graph["horon village"]})
+	if !reached[r.Graph["done"]] {
+		t.Error("goal not reachable in assumed fill route")
+	}
+}
+
+// impossible conditions should result in an error, not a fatal or hang
+func TestFindAssumedRouteImpossible(t *testing.T) {
+	start := []string{"horon village"}
+	r := NewRoute(start)
+
+	_, _, err := findAssumedRoute(rand.New(rand.NewSource(0)), r, start,
+		[]string{"d1 essence"}, []string{"ember seeds"}, -1)
+	if err == nil {
+		t.Error("no error for impossible goal/forbid combination")
+	}
+}
+
+func TestAssumedLevels(t *testing.T) {
+	g := graph.New()
+	g.AddNodes(
+		graph.NewNode("start", graph.RootType, false),
+		graph.NewNode("item A", graph.OrType, false),
+		graph.NewNode("item B", graph.OrType, false),
+		graph.NewNode("item C", graph.OrType, false),
+		graph.NewNode("slot A", graph.AndType, false),
+		graph.NewNode("slot B", graph.OrType, false),
+		graph.NewNode("slot C", graph.AndType, false))
+	g.AddParents(map[string][]string{
+		"slot A": {"start"},
+		"slot B": {"item C", "item B"},
+		"slot C": {"item A", "item C"},
+	})
+
+	levels := assumedLevels([]*graph.Node{g["start"]},
+		[]*graph.Node{g["item A"], g["item B"], g["item C"]})
+	want := map[string]int{"start": 0, "slot A": 0, "item A": 1, "item B": 2,
+		"slot B": 2, "item C": 3, "slot C": 3}
+	for name, level := range want {
+		if got, ok := levels[g[name]]; !ok || got != level {
+			t.Errorf("%s: want level %d, got %d (%v)", name, level, got, ok)
+		}
+	}
+}
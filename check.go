@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+// reads the item placements from already-randomized ROM data, and writes
+// whether the seed can be beaten with them, along with any softlock found.
+// returns true iff the seed is beatable and no softlock was found. problems
+// reading individual slots are written as warnings rather than returned.
+//
+// the ROM doesn't record the settings it was made with, so keysanity and
+// chests are always assumed, which works for ROMs made without them too.
+// settings whose ROM data isn't known (seasons, essences, etc) can't be
+// detected.
+func checkSeed(w io.Writer, b []byte) (bool, error) {
+	rings, err := rom.ReadRings(b)
+	if err != nil {
+		return false, err
+	}
+	ringTreasures, err := rom.RingTreasures(rings)
+	if err != nil {
+		return false, err
+	}
+	slots, errs := rom.ReadSlots(b)
+	if slots == nil {
+		return false, errs[0]
+	}
+	for _, err := range errs {
+		fmt.Fprintf(w, "warning: %v\n", err)
+	}
+	renameRings(slots, ringTreasures)
+
+	start := []string{"horon village"}
+	s := &Settings{Keysanity: true, Chests: true, Essences: 8}
+	prenodes, items := settingsPrenodes(s, &Choices{Rings: rings})
+	r := newRouteFromPrenodes(start, prenodes, items)
+
+	placed, errs := matchPlacements(r, slots)
+	for _, err := range errs {
+		fmt.Fprintf(w, "warning: %v\n", err)
+	}
+	for _, err := range linkPlacements(r, placed) {
+		fmt.Fprintf(w, "warning: %v\n", err)
+	}
+
+	g := r.Graph
+	startNodes := make([]*graph.Node, len(start))
+	for i, name := range start {
+		startNodes[i] = g[name]
+	}
+	reached := g.Explore(make(map[*graph.Node]bool), startNodes)
+
+	ok := reached[g["done"]]
+	if ok {
+		fmt.Fprintln(w, "done is reachable")
+	} else {
+		fmt.Fprintln(w, "done is not reachable:")
+		writeBlockers(w, r, reached, g["done"], 1,
+			make(map[*graph.Node]bool))
+	}
+
+	// the softlock checks use the marks left by Explore
+	if err := canSoftlock(g); err != nil {
+		fmt.Fprintf(w, "softlock: %v\n", err)
+		ok = false
+	}
+
+	return ok, nil
+}
+
+// renames the ring treasures read from the ROM after the rings they give, since
+// rom.ReadSlots only knows them by the rings they give in the original game.
+func renameRings(slots map[string][]string,
+	ringTreasures map[string]*rom.Treasure) {
+	names := make(map[string]string, len(ringTreasures))
+	for ringName, t := range ringTreasures {
+		for _, name := range rom.FindTreasures(t.ID(), t.SubID()) {
+			names[name] = ringName
+		}
+	}
+
+	for _, treasures := range slots {
+		for i, name := range treasures {
+			if ringName, ok := names[name]; ok {
+				treasures[i] = ringName
+			}
+		}
+	}
+}
+
+// returns a map of slot names to the names of the items in the route's pool
+// that match the treasures read from the ROM. treasures don't say which item
+// node they are (keys are generic in the game, and some treasures share IDs),
+// so each slot gets the item it originally held if that matches, and otherwise
+// the first matching item that's left.
+func matchPlacements(r *Route,
+	slots map[string][]string) (map[string]string, []error) {
+	placed := make(map[string]string, len(r.Slots))
+	used := make(map[string]bool, len(r.Slots))
+	errs := make([]error, 0)
+
+	// returns the names of unused items that the slot's treasures could be
+	matches := func(slotName string) []string {
+		names := make([]string, 0)
+		for _, itemName := range sortedNodeNames(r.Items) {
+			if used[itemName] || isOutOfDungeon(itemName, slotName) {
+				continue
+			}
+			treasure := rom.TreasureName(itemTreasure(itemName))
+			if isRingItem(itemName) {
+				treasure = itemName // see renameRings
+			}
+			for _, name := range slots[slotName] {
+				if treasure == name {
+					names = append(names, itemName)
+					break
+				}
+			}
+		}
+		return names
+	}
+
+	// first put items back where they came from, then fill in the rest
+	slotNames := sortedSlotNames(r)
+	for _, slotName := range slotNames {
+		original := originalItem(slotName)
+		for _, itemName := range matches(slotName) {
+			if itemName == original {
+				placed[slotName], used[itemName] = itemName, true
+				break
+			}
+		}
+	}
+	for _, slotName := range slotNames {
+		if placed[slotName] != "" {
+			continue
+		}
+		if _, ok := slots[slotName]; !ok {
+			continue // already warned about by rom.ReadSlots
+		}
+		if names := matches(slotName); len(names) > 0 {
+			placed[slotName], used[names[0]] = names[0], true
+		} else {
+			errs = append(errs, fmt.Errorf("%s: no item in the pool for %v",
+				slotName, slots[slotName]))
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool {
+		return errs[i].Error() < errs[j].Error()
+	})
+	return placed, errs
+}
+
+// returns the name of the item node for what's in the slot in the original
+// game.
+func originalItem(slotName string) string {
+	if key, ok := keysanityChests[slotName]; ok {
+		return key
+	}
+	if item, ok := fillerChests[slotName]; ok {
+		return item
+	}
+	return rom.TreasureName(rom.ItemSlots[slotName].Treasure)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+// returns fake JP ROM data with the original treasures in every slot, and
+// nothing else.
+func makeVanillaROM() []byte {
+	b := make([]byte, 0x100000)
+	copy(b[0x134:], "ZELDA DIN")
+	copy(b[0x13f:], "AZ7J")
+	for _, t := range rom.Treasures {
+		t.Mutate(b)
+	}
+	for _, slot := range rom.ItemSlots {
+		slot.Mutate(b)
+	}
+	return b
+}
+
+func TestCheckSeed(t *testing.T) {
+	// the original game is beatable
+	b := makeVanillaROM()
+	w := new(bytes.Buffer)
+	ok, err := checkSeed(w, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || strings.Contains(w.String(), "warning") {
+		t.Errorf("vanilla seed reported as unbeatable:\n%s", w.String())
+	}
+
+	// but not without the gnarled key
+	slot := rom.ItemSlots["maku key fall"]
+	for _, addr := range slot.IDAddrs {
+		b[addr.FullOffset()] = 0xff
+	}
+	w.Reset()
+	if ok, err = checkSeed(w, b); err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Error("seed without gnarled key reported as beatable")
+	}
+	for _, want := range []string{
+		"warning: maku key fall: unknown treasure ff",
+		"done is not reachable:",
+		"gnarled key (not placed)",
+	} {
+		if !strings.Contains(w.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, w.String())
+		}
+	}
+
+	// and it's not an OOS ROM at all
+	if _, err := checkSeed(w, make([]byte, 0x100000)); err == nil {
+		t.Error("no error for non-OOS ROM")
+	}
+}
+
+func TestCheckRandomizedSeed(t *testing.T) {
+	start := []string{"horon village"}
+	r := NewRoute(start)
+	usedItems, usedSlots, err := findRoute(rand.New(rand.NewSource(0)), r,
+		start, []string{"done"}, []string{}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rings, err := rom.RingTreasures(defaultRings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// write the IDs directly, so that the slots and treasures in the rom
+	// package are left alone
+	b := makeVanillaROM()
+	se := usedSlots.Front()
+	for ie := usedItems.Front(); ie != nil; ie = ie.Next() {
+		treasure := seedTreasure(rings, ie.Value.(*graph.Node).Name)
+		slot := rom.ItemSlots[se.Value.(*graph.Node).Name]
+		for _, addr := range slot.IDAddrs {
+			b[addr.FullOffset()] = treasure.ID()
+		}
+		for _, addr := range slot.SubIDAddrs {
+			b[addr.FullOffset()] = treasure.SubID()
+		}
+		se = se.Next()
+	}
+
+	w := new(bytes.Buffer)
+	if ok, err := checkSeed(w, b); err != nil {
+		t.Fatal(err)
+	} else if !ok {
+		t.Errorf("randomized seed reported as unbeatable:\n%s", w.String())
+	}
+}
+
+func TestMatchPlacements(t *testing.T) {
+	prenodes, items := settingsPrenodes(
+		&Settings{Keysanity: true, Chests: true, Essences: 8},
+		&Choices{Rings: defaultRings})
+	r := newRouteFromPrenodes([]string{"horon village"}, prenodes, items)
+
+	// keys are generic, so each chest should get back the key for its door
+	placed, errs := matchPlacements(r, map[string][]string{
+		"d2 blade key chest": {"chest small key"},
+		"d2 bomb key chest":  {"chest small key"},
+		"d1 bomb chest":      {"bombs", "chest bombs"},
+	})
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	for slot, want := range map[string]string{
+		"d2 blade key chest": "d2 key C",
+		"d2 bomb key chest":  "d2 key B",
+		"d1 bomb chest":      "d1 bombs",
+	} {
+		if placed[slot] != want {
+			t.Errorf("%s: want %s, got %s", slot, want, placed[slot])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+// in chests mode, these chests become item slots, and the things normally in
+// them become filler items in the pool, so that there's something to put in
+// every chest. filler items are numbered by the dungeon they come from, since
+// each item needs its own node.
+//
+// only the d0-d2 chest addresses are known so far; the chests in other
+// dungeons stay as they are.
+var fillerChests = map[string]string{
+	"d0 rupee chest":    "d0 30 rupees",
+	"d1 map chest":      "d1 map",
+	"d1 compass chest":  "d1 compass",
+	"d1 gasha chest":    "d1 gasha seed",
+	"d1 bomb chest":     "d1 bombs",
+	"d2 5-rupee chest":  "d2 5 rupees",
+	"d2 compass chest":  "d2 compass",
+	"d2 map chest":      "d2 map",
+	"d2 10-rupee chest": "d2 10 rupees",
+}
+
+// maps filler items to the names of their treasures
+var fillerTreasures = map[string]string{
+	"d0 30 rupees":  "chest 30 rupees",
+	"d1 map":        "chest map",
+	"d1 compass":    "chest compass",
+	"d1 gasha seed": "chest gasha seed",
+	"d1 bombs":      "chest bombs",
+	"d2 5 rupees":   "chest 5 rupees",
+	"d2 compass":    "chest compass",
+	"d2 map":        "chest map",
+	"d2 10 rupees":  "chest 10 rupees",
+}
+
+// maps and compasses only work in the dungeon they're found in
+var mapCompassRegexp = regexp.MustCompile(`^d\d (map|compass)$`)
+
+// changes the prenode and item maps so that the filler chests are slots and
+// their contents are items.
+func applyChests(prenodes, items map[string]*prenode.Prenode) {
+	for chest, item := range fillerChests {
+		makeSlot(prenodes, chest)
+		prenodes[item] = prenode.Root()
+		items[item] = prenodes[item]
+	}
+}
+
+// replaces the named prenode with an equivalent slot prenode
+func makeSlot(prenodes map[string]*prenode.Prenode, name string) {
+	pn := prenodes[name]
+	switch pn.Type {
+	case prenode.AndType:
+		prenodes[name] = prenode.AndSlot(pn.Parents...)
+	case prenode.OrType:
+		prenodes[name] = prenode.OrSlot(pn.Parents...)
+	}
+}
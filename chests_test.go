@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+func TestChests(t *testing.T) {
+	start, goal := []string{"horon village"}, []string{"done"}
+	prenodes, items := settingsPrenodes(&Settings{Keysanity: true, Chests: true},
+		&Choices{})
+	r := newRouteFromPrenodes(start, prenodes, items)
+
+	for chest, item := range fillerChests {
+		if r.Slots[chest] == nil {
+			t.Errorf("%s is not a slot in chests mode", chest)
+		}
+		if itemTreasure(item) == nil {
+			t.Errorf("no treasure for %s", item)
+		}
+	}
+
+	usedItems, usedSlots, err := findRoute(
+		rand.New(rand.NewSource(0)), r, start, goal, []string{}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usedSlots.Len() != len(r.Slots) {
+		t.Errorf("want %d slots filled, got %d", len(r.Slots), usedSlots.Len())
+	}
+
+	// maps and compasses have to be in their own dungeons
+	se := usedSlots.Front()
+	for ie := usedItems.Front(); ie != nil; ie = ie.Next() {
+		item, slot := ie.Value.(*graph.Node).Name, se.Value.(*graph.Node).Name
+		if mapCompassRegexp.MatchString(item) && isOutOfDungeon(item, slot) {
+			t.Errorf("%s placed outside its dungeon in %s", item, slot)
+		}
+		se = se.Next()
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+func TestEssences(t *testing.T) {
+	start := []string{"horon village"}
+	prenodes, items := settingsPrenodes(&Settings{Essences: 2}, &Choices{})
+	r := newRouteFromPrenodes(start, prenodes, items)
+
+	seed := r.Graph["maku seed"]
+	if seed.Type != graph.CountType || seed.Threshold != 2 {
+		t.Fatalf("maku seed is type %d with threshold %d",
+			seed.Type, seed.Threshold)
+	}
+
+	// any two essences should do
+	reached := r.Graph.Explore(make(map[*graph.Node]bool),
+		[]*graph.Node{r.Graph["d3 essence"]})
+	if reached[seed] {
+		t.Error("maku seed reached with one essence")
+	}
+	reached = r.Graph.Explore(reached, []*graph.Node{r.Graph["d7 essence"]})
+	if !reached[seed] {
+		t.Error("maku seed not reached with two essences")
+	}
+
+	if _, _, err := findRoute(rand.New(rand.NewSource(0)), r, start,
+		[]string{"done"}, []string{}, -1); err != nil {
+		t.Fatal(err)
+	}
+}
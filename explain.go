@@ -0,0 +1,216 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+// writes an explanation of how the named node is reached from the start nodes,
+// with the given slot -> item placements. if it isn't reachable, the missing
+// parents that block it are written instead.
+func explainNode(w io.Writer, r *Route, start []string, name string,
+	placed map[string]string) error {
+	g := r.Graph
+	if err := checkNodeNames(g, append([]string{name}, start...)); err != nil {
+		return err
+	}
+	for _, err := range linkPlacements(r, placed) {
+		fmt.Fprintf(w, "warning: %v\n", err)
+	}
+
+	startNodes := make([]*graph.Node, len(start))
+	for i, startName := range start {
+		startNodes[i] = g[startName]
+	}
+	reached := g.Explore(make(map[*graph.Node]bool), startNodes)
+
+	if !reached[g[name]] {
+		fmt.Fprintf(w, "%s is not reachable:\n", name)
+		writeBlockers(w, r, reached, g[name], 1, make(map[*graph.Node]bool))
+		return nil
+	}
+
+	// the nodes' GetMark functions record the parents that satisfied them
+	g.ClearMarks()
+	path := list.New()
+	if g[name].GetMark(g[name], path) != graph.MarkTrue {
+		return fmt.Errorf("%s is reachable, but no path to it was found", name)
+	}
+	reasons := make(map[string]string, path.Len())
+	for e := path.Front(); e != nil; e = e.Next() {
+		line := e.Value.(string)
+		child, parents := line, ""
+		if i := strings.Index(line, " <- "); i != -1 {
+			child, parents = line[:i], line[i+len(" <- "):]
+		}
+		reasons[child] = parents
+	}
+
+	fmt.Fprintf(w, "%s is reachable:\n", name)
+	writeReasons(w, g[name], reasons, 1, make(map[*graph.Node]bool))
+	return nil
+}
+
+// returns the route and start nodes for placements made with the given
+// settings and choices (from a spoiler log), or the default route if they're
+// nil.
+func placementRoute(s *Settings, c *Choices) (*Route, []string) {
+	start := []string{"horon village"}
+	if s == nil {
+		return NewRoute(start), start
+	}
+	if c == nil {
+		c = &Choices{}
+	}
+	start = append(start, s.StartItems...)
+	prenodes, items := settingsPrenodes(s, c)
+	return newRouteFromPrenodes(start, prenodes, items), start
+}
+
+// links the placed items to their slots in the route's graph, and returns an
+// error for each placement that has to be skipped because its slot or item
+// isn't part of the route. spoiler logs include every slot, so slots that
+// aren't part of the route are skipped without an error if they hold what
+// they do in the original game.
+func linkPlacements(r *Route, placed map[string]string) []error {
+	slotNames := make([]string, 0, len(placed))
+	for slotName := range placed {
+		slotNames = append(slotNames, slotName)
+	}
+	sort.Strings(slotNames)
+
+	errs := make([]error, 0)
+	for _, slotName := range slotNames {
+		itemName := placed[slotName]
+		slot, item := r.Slots[slotName], r.Items[itemName]
+		switch {
+		case slot != nil && item != nil:
+			item.AddParents(slot)
+		case slot == nil && rom.ItemSlots[slotName] != nil &&
+			rom.TreasureName(rom.ItemSlots[slotName].Treasure) == itemName:
+			// unchanged, and the logic already assumes it
+		case slot == nil:
+			errs = append(errs, fmt.Errorf("%s: not a slot in this route",
+				slotName))
+		default:
+			errs = append(errs, fmt.Errorf("%s: %s is not an item in this route",
+				slotName, itemName))
+		}
+	}
+	return errs
+}
+
+// returns the parents of the node that justified its mark, according to the
+// reasons recorded by GetMark.
+func justifyingParents(n *graph.Node, reasons map[string]string) []*graph.Node {
+	switch n.Type {
+	case graph.OrType:
+		// or nodes record exactly one parent, so the name can't be ambiguous
+		for _, parent := range n.Parents {
+			if parent.Name == reasons[n.Name] {
+				return []*graph.Node{parent}
+			}
+		}
+	case graph.CountType:
+		// only the first threshold parents are needed
+		parents := make([]*graph.Node, 0, n.Threshold)
+		for _, parent := range n.Parents {
+			if _, ok := reasons[parent.Name]; ok {
+				parents = append(parents, parent)
+				if len(parents) == n.Threshold {
+					break
+				}
+			}
+		}
+		return parents
+	default:
+		return n.Parents
+	}
+	return nil
+}
+
+// writes the node and the parents that justify it, recursively. nodes that
+// were already explained are only referred to.
+func writeReasons(w io.Writer, n *graph.Node, reasons map[string]string,
+	depth int, seen map[*graph.Node]bool) {
+	indent := strings.Repeat("  ", depth)
+	if seen[n] {
+		fmt.Fprintf(w, "%s%s (see above)\n", indent, n.Name)
+		return
+	}
+	seen[n] = true
+
+	parents := justifyingParents(n, reasons)
+	if len(parents) == 0 {
+		fmt.Fprintf(w, "%s%s (given)\n", indent, n.Name)
+		return
+	}
+	fmt.Fprintf(w, "%s%s <- %s\n", indent, n.Name, joinNodeNames(parents))
+	for _, parent := range parents {
+		writeReasons(w, parent, reasons, depth+1, seen)
+	}
+}
+
+// writes the unreached parents that keep the node from being reached. only the
+// parents of and nodes are followed, since every one of those is needed;
+// following every alternative of an or node would write most of the graph.
+// nodes that were already written are only referred to.
+func writeBlockers(w io.Writer, r *Route, reached map[*graph.Node]bool,
+	n *graph.Node, depth int, seen map[*graph.Node]bool) {
+	indent := strings.Repeat("  ", depth)
+	if seen[n] {
+		fmt.Fprintf(w, "%s%s (see above)\n", indent, n.Name)
+		return
+	}
+	seen[n] = true
+
+	missing := make([]*graph.Node, 0)
+	for _, parent := range n.Parents {
+		if !reached[parent] {
+			missing = append(missing, parent)
+		}
+	}
+
+	switch {
+	case n.Type == graph.RootType:
+		fmt.Fprintf(w, "%s%s (never reachable)\n", indent, n.Name)
+	case len(n.Parents) == 0 && r.Items[n.Name] != nil:
+		fmt.Fprintf(w, "%s%s (not placed)\n", indent, n.Name)
+	case len(n.Parents) == 0:
+		fmt.Fprintf(w, "%s%s (no parents)\n", indent, n.Name)
+	case n.Type == graph.AndType:
+		fmt.Fprintf(w, "%s%s needs all of: %s\n",
+			indent, n.Name, joinNodeNames(missing))
+	case n.Type == graph.OrType:
+		fmt.Fprintf(w, "%s%s needs one of: %s\n",
+			indent, n.Name, joinNodeNames(missing))
+	case n.Type == graph.CountType:
+		fmt.Fprintf(w, "%s%s needs %d more of: %s\n", indent, n.Name,
+			n.Threshold-(len(n.Parents)-len(missing)), joinNodeNames(missing))
+	}
+
+	if n.Type == graph.AndType {
+		for _, parent := range missing {
+			writeBlockers(w, r, reached, parent, depth+1, seen)
+		}
+	}
+}
+
+// returns the names of the nodes, quoted if they contain commas, separated by
+// commas.
+func joinNodeNames(nodes []*graph.Node) string {
+	names := make([]string, len(nodes))
+	for i, node := range nodes {
+		names[i] = node.Name
+		if strings.Contains(node.Name, ",") {
+			names[i] = `"` + node.Name + `"`
+		}
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExplainNode(t *testing.T) {
+	start := []string{"horon village"}
+
+	// reachable through a placed item
+	b := new(bytes.Buffer)
+	if err := explainNode(b, NewRoute(start), start, "maku key fall",
+		map[string]string{"d0 sword chest": "sword L-1"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"maku key fall is reachable:",
+		"    pop maku bubble <- sword\n",
+		"        sword L-1 <- d0 sword chest\n",
+		"    horon village (given)\n",
+		"(see above)",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("explanation missing %q:\n%s", want, b.String())
+		}
+	}
+
+	// blocked without it
+	b.Reset()
+	if err := explainNode(b, NewRoute(start), start, "maku key fall",
+		nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"maku key fall is not reachable:",
+		"  maku key fall needs all of: pop maku bubble\n",
+		"    pop maku bubble needs one of: sword,",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("explanation missing %q:\n%s", want, b.String())
+		}
+	}
+
+	// unplaced items are called out
+	b.Reset()
+	if err := explainNode(b, NewRoute(start), start, "enter d1",
+		nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "gnarled key (not placed)") {
+		t.Errorf("explanation missing unplaced item:\n%s", b.String())
+	}
+
+	if err := explainNode(b, NewRoute(start), start, "d1 esence",
+		nil); err == nil {
+		t.Error("no error for nonexistent node")
+	}
+
+	// placements that aren't part of the route are warned about, unless
+	// they're what's there in the original game
+	b.Reset()
+	if err := explainNode(b, NewRoute(start), start, "enter d1",
+		map[string]string{
+			"d2 blade key chest": "d2 key C",
+			"d1 map chest":       "chest map",
+			"maku key fall":      "fake item",
+		}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"warning: d2 blade key chest: not a slot in this route\n",
+		"warning: maku key fall: fake item is not an item in this route\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("explanation missing %q:\n%s", want, b.String())
+		}
+	}
+	if strings.Count(b.String(), "warning") != 2 {
+		t.Errorf("wrong number of warnings:\n%s", b.String())
+	}
+
+	// but not with the settings the placements were made with
+	b.Reset()
+	r, start := placementRoute(&Settings{Keysanity: true, Essences: 8},
+		&Choices{Rings: defaultRings})
+	if err := explainNode(b, r, start, "d2 key C", map[string]string{
+		"d2 blade key chest": "d2 key C",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "warning") || !strings.Contains(b.String(),
+		"d2 key C needs one of: d2 blade key chest") {
+		t.Errorf("keysanity placement not linked:\n%s", b.String())
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+// writes the graph for the given settings and choices (see placementRoute) to
+// the named file, as GraphML if the filename ends in .graphml and as DOT
+// otherwise. if node isn't empty, only nodes within depth links of it are
+// written. if placed isn't nil, its slot -> item placements are linked in the
+// graph and drawn as route edges, and everything reachable with them is
+// highlighted.
+func exportGraph(filename, node string, depth int, s *Settings, c *Choices,
+	placed map[string]string) error {
+	r, start := placementRoute(s, c)
+	g := r.Graph
+
+	slots := make(map[string]bool, len(r.Slots))
+	for name := range r.Slots {
+		slots[name] = true
+	}
+	opts := &graph.ExportOptions{Slots: slots}
+
+	if placed != nil {
+		errs := linkPlacements(r, placed)
+		for _, err := range errs {
+			log.Printf("warning: %v", err)
+		}
+		if len(errs) > 0 {
+			log.Printf("warning: skipped %d of %d placements", len(errs),
+				len(placed))
+		}
+		opts.Placed = placed
+
+		startNodes := make([]*graph.Node, len(start))
+		for i, name := range start {
+			startNodes[i] = g[name]
+		}
+		opts.Reached = g.Explore(make(map[*graph.Node]bool), startNodes)
+	}
+
+	if node != "" {
+		if err := checkNodeNames(g, []string{node}); err != nil {
+			return err
+		}
+		g = g.Neighborhood(node, depth)
+	}
+
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(filename)) == ".graphml" {
+		err = g.WriteGraphML(f, opts)
+	} else {
+		err = g.WriteDOT(f, opts)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportGraphSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oosrando")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "graph.dot")
+
+	// the key chest is only a slot with keysanity, so the placement has to be
+	// drawn with those settings
+	placed := map[string]string{"d1 key chest": "d1 key B"}
+	if err := exportGraph(filename, "d1 key chest", 1,
+		&Settings{Keysanity: true}, nil, placed); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"d1 key chest" -> "d1 key B" [style=dashed`
+	if !strings.Contains(string(b), want) {
+		t.Errorf("graph missing %q:\n%s", want, b)
+	}
+}
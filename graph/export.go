@@ -0,0 +1,240 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportOptions are extra information to include when exporting a graph. The
+// graph itself doesn't know which nodes are slots or what's been placed where,
+// so that has to come from the caller. Any of the fields can be nil.
+type ExportOptions struct {
+	Slots   map[string]bool   // names of slot nodes
+	Placed  map[string]string // slot name -> item name, drawn as route edges
+	Reached map[*Node]bool    // highlighted nodes, like the result of Explore
+}
+
+// Neighborhood returns the subgraph of nodes that are within depth parent
+// links above or child links below the named node, including the node itself.
+// A negative depth means no limit. Nodes in the subgraph are shared with the
+// original graph, and still have parents and children outside it.
+func (g Graph) Neighborhood(name string, depth int) Graph {
+	sub := New()
+	root := g[name]
+	if root == nil {
+		return sub
+	}
+	sub[name] = root
+
+	// go up and down separately, so that siblings aren't included just for
+	// sharing a parent
+	for _, up := range []bool{true, false} {
+		seen := map[*Node]bool{root: true}
+		frontier := []*Node{root}
+		for i := 0; len(frontier) > 0 && (depth < 0 || i < depth); i++ {
+			next := make([]*Node, 0)
+			for _, node := range frontier {
+				links := node.Children
+				if up {
+					links = node.Parents
+				}
+				for _, link := range links {
+					if !seen[link] && g[link.Name] == link {
+						seen[link] = true
+						sub[link.Name] = link
+						next = append(next, link)
+					}
+				}
+			}
+			frontier = next
+		}
+	}
+
+	return sub
+}
+
+// an edge between two nodes in an exported graph, in parent -> child order
+type exportEdge struct {
+	from, to string
+	placed   bool
+}
+
+// returns the names of the nodes in the graph in sorted order, so that output
+// is always the same for the same graph.
+func (g Graph) sortedNames() []string {
+	names := make([]string, 0, len(g))
+	for name := range g {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// returns the edges between nodes in the graph, with route edges for any
+// placements whose slot and item are both in the graph. placements that have
+// already been linked in the graph aren't duplicated.
+func (g Graph) exportEdges(opts *ExportOptions) []exportEdge {
+	edges := make([]exportEdge, 0)
+	for _, name := range g.sortedNames() {
+		for _, parent := range g[name].Parents {
+			if g[parent.Name] != parent || opts.Placed[parent.Name] == name {
+				continue
+			}
+			edges = append(edges, exportEdge{parent.Name, name, false})
+		}
+	}
+
+	slots := make([]string, 0, len(opts.Placed))
+	for slot := range opts.Placed {
+		slots = append(slots, slot)
+	}
+	sort.Strings(slots)
+	for _, slot := range slots {
+		if g[slot] != nil && g[opts.Placed[slot]] != nil {
+			edges = append(edges, exportEdge{slot, opts.Placed[slot], true})
+		}
+	}
+
+	return edges
+}
+
+// returns the name of a node's type as used in exported graphs
+func typeName(n *Node) string {
+	switch n.Type {
+	case RootType:
+		return "root"
+	case AndType:
+		return "and"
+	case OrType:
+		return "or"
+	case CountType:
+		return "count"
+	}
+	return "unknown"
+}
+
+// WriteDOT writes the graph in Graphviz DOT format. Node shapes depend on
+// type, steps are drawn bold, slots are filled, and reached nodes are
+// outlined in green. Route edges are dashed.
+func (g Graph) WriteDOT(w io.Writer, opts *ExportOptions) error {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph logic {")
+	fmt.Fprintln(bw, "\tnode [fontname=\"sans-serif\"];")
+	for _, name := range g.sortedNames() {
+		n := g[name]
+		attrs := make([]string, 0)
+
+		switch n.Type {
+		case RootType:
+			attrs = append(attrs, "shape=doublecircle")
+		case AndType:
+			attrs = append(attrs, "shape=box")
+		case OrType:
+			attrs = append(attrs, "shape=ellipse")
+		case CountType:
+			attrs = append(attrs, "shape=octagon", "label="+strconv.Quote(
+				fmt.Sprintf("%s\n%d of %d", name, n.Threshold, len(n.Parents))))
+		}
+
+		styles := make([]string, 0)
+		if n.IsStep {
+			styles = append(styles, "bold")
+		}
+		if opts.Slots[name] {
+			styles = append(styles, "filled")
+			attrs = append(attrs, "fillcolor=lightblue")
+		}
+		if len(styles) > 0 {
+			attrs = append(attrs,
+				"style="+strconv.Quote(strings.Join(styles, ",")))
+		}
+		if opts.Reached[n] {
+			attrs = append(attrs, "color=forestgreen", "penwidth=2")
+		}
+
+		fmt.Fprintf(bw, "\t%s [%s];\n", strconv.Quote(name),
+			strings.Join(attrs, ", "))
+	}
+
+	for _, edge := range g.exportEdges(opts) {
+		if edge.placed {
+			fmt.Fprintf(bw, "\t%s -> %s [style=dashed, color=blue];\n",
+				strconv.Quote(edge.from), strconv.Quote(edge.to))
+		} else {
+			fmt.Fprintf(bw, "\t%s -> %s;\n",
+				strconv.Quote(edge.from), strconv.Quote(edge.to))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+
+	return bw.Flush()
+}
+
+// WriteGraphML writes the graph in GraphML format, with the node type, step,
+// slot, reached, and threshold information as data attributes, and route
+// edges marked as placed.
+func (g Graph) WriteGraphML(w io.Writer, opts *ExportOptions) error {
+	if opts == nil {
+		opts = &ExportOptions{}
+	}
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw,
+		`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, key := range []struct{ id, target, kind string }{
+		{"type", "node", "string"},
+		{"step", "node", "boolean"},
+		{"slot", "node", "boolean"},
+		{"reached", "node", "boolean"},
+		{"threshold", "node", "int"},
+		{"placed", "edge", "boolean"},
+	} {
+		fmt.Fprintf(bw, `  <key id="%s" for="%s" attr.name="%s" `+
+			`attr.type="%s"/>`+"\n", key.id, key.target, key.id, key.kind)
+	}
+	fmt.Fprintln(bw, `  <graph id="logic" edgedefault="directed">`)
+
+	for _, name := range g.sortedNames() {
+		n := g[name]
+		fmt.Fprintf(bw, "    <node id=\"%s\">\n", escapeXML(name))
+		fmt.Fprintf(bw, "      <data key=\"type\">%s</data>\n", typeName(n))
+		fmt.Fprintf(bw, "      <data key=\"step\">%t</data>\n", n.IsStep)
+		fmt.Fprintf(bw, "      <data key=\"slot\">%t</data>\n",
+			opts.Slots[name])
+		fmt.Fprintf(bw, "      <data key=\"reached\">%t</data>\n",
+			opts.Reached[n])
+		if n.Type == CountType {
+			fmt.Fprintf(bw, "      <data key=\"threshold\">%d</data>\n",
+				n.Threshold)
+		}
+		fmt.Fprintln(bw, "    </node>")
+	}
+
+	for i, edge := range g.exportEdges(opts) {
+		fmt.Fprintf(bw, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">"+
+			"<data key=\"placed\">%t</data></edge>\n",
+			i, escapeXML(edge.from), escapeXML(edge.to), edge.placed)
+	}
+
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+
+	return bw.Flush()
+}
+
+// returns the string with XML special characters escaped
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
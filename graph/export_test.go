@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+// returns a small graph: a and b are parents of c, which is a parent of slot,
+// and item is a separate node that can be placed in slot.
+func makeExportGraph() Graph {
+	g := New()
+	a := NewNode("a", AndType, false)
+	b := NewNode("b", OrType, false)
+	c := NewNode("c", CountType, true)
+	c.Threshold = 1
+	slot := NewNode("slot", AndType, true)
+	item := NewNode("item", OrType, false)
+	g.AddNodes(a, b, c, slot, item)
+	g.AddParents(map[string][]string{
+		"c":    {"a", "b"},
+		"slot": {"c"},
+	})
+	return g
+}
+
+func TestNeighborhood(t *testing.T) {
+	g := makeExportGraph()
+
+	sub := g.Neighborhood("c", 1)
+	for _, name := range []string{"a", "b", "c", "slot"} {
+		if sub[name] == nil {
+			t.Errorf("neighborhood of c missing %s", name)
+		}
+	}
+	if sub["item"] != nil {
+		t.Error("neighborhood of c includes unlinked node")
+	}
+
+	// siblings aren't included by going up and then back down
+	sub = g.Neighborhood("a", -1)
+	if sub["b"] != nil || sub["slot"] == nil {
+		t.Errorf("bad neighborhood of a: %v", sub.sortedNames())
+	}
+
+	if len(g.Neighborhood("nonexistent", 1)) != 0 {
+		t.Error("neighborhood of nonexistent node not empty")
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	g := makeExportGraph()
+	g["item"].AddParents(g["slot"])
+	opts := &ExportOptions{
+		Slots:   map[string]bool{"slot": true},
+		Placed:  map[string]string{"slot": "item"},
+		Reached: map[*Node]bool{g["a"]: true},
+	}
+
+	b := new(bytes.Buffer)
+	if err := g.WriteDOT(b, opts); err != nil {
+		t.Fatal(err)
+	}
+	s := b.String()
+
+	for _, want := range []string{
+		`"a" [shape=box, color=forestgreen, penwidth=2];`,
+		`"b" [shape=ellipse];`,
+		`"c" [shape=octagon, label="c\n1 of 2", style="bold"];`,
+		`"slot" [shape=box, fillcolor=lightblue, style="bold,filled"];`,
+		`"a" -> "c";`,
+		`"slot" -> "item" [style=dashed, color=blue];`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("DOT output missing %s", want)
+		}
+	}
+
+	// the placement shouldn't also be drawn as a normal edge
+	if strings.Contains(s, `"slot" -> "item";`) {
+		t.Error("DOT output duplicates route edge")
+	}
+
+	// and a nil options argument is fine
+	if err := g.WriteDOT(new(bytes.Buffer), nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	g := makeExportGraph()
+	g.AddNodes(NewNode("a & <b>", RootType, false))
+	opts := &ExportOptions{Placed: map[string]string{"slot": "item"}}
+
+	b := new(bytes.Buffer)
+	if err := g.WriteGraphML(b, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	// make sure the output is well-formed, and count the elements
+	counts := make(map[string]int)
+	d := xml.NewDecoder(b)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if elem, ok := tok.(xml.StartElement); ok {
+			counts[elem.Name.Local]++
+		}
+	}
+
+	if counts["node"] != 6 {
+		t.Errorf("expected 6 nodes, got %d", counts["node"])
+	}
+	if counts["edge"] != 4 {
+		t.Errorf("expected 4 edges, got %d", counts["edge"])
+	}
+}
@@ -46,9 +46,11 @@ func (g Graph) ClearMarks() {
 }
 
 // Explore returns a new set of all nodes reachable from the set of nodes in
-// start, adding the nodes in add. This is a destructive operation; at the end,
-// the graph will have all nodes in the return set set to MarkTrue and the rest
-// set to MarkNone.
+// start, adding the nodes in add. The start set is assumed to already be
+// everything reachable from itself, as it is when it's the result of a
+// previous call. This is a destructive operation; at the end, the graph will
+// have all nodes in the return set set to MarkTrue and the rest set to
+// MarkNone.
 func (g Graph) Explore(start map[*Node]bool, add []*Node) map[*Node]bool {
 	// copy set, and mark nodes accordingly
 	g.ClearMarks()
@@ -58,43 +60,32 @@ func (g Graph) Explore(start map[*Node]bool, add []*Node) map[*Node]bool {
 		node.Mark = MarkTrue
 	}
 
-	// make set of unchecked children
-	frontier := make(map[*Node]bool)
-
-	// add nodes to the reached set and add their unreached children to the
-	// frontier.
+	// make queue of newly reached nodes whose children need to be checked.
+	// this is a slice instead of a set so that nodes are always checked in
+	// the same order.
+	frontier := make([]*Node, 0, len(add))
 	for _, node := range add {
-		reached[node] = true
-		node.Mark = MarkTrue
-
-		for _, child := range node.Children {
-			if !reached[child] {
-				frontier[child] = true
-			}
+		if !reached[node] {
+			reached[node] = true
+			node.Mark = MarkTrue
+			frontier = append(frontier, node)
 		}
 	}
 
-	// make set of nodes that were already processed as parents
-	tried := make(map[*Node]bool)
-
-	// explore. done when no new nodes are reached in an iteration
+	// explore. done when no new nodes are reached. children are checked only
+	// against the marks of their parents, so nothing is evaluated more than
+	// once per newly reached parent, no matter how much of the graph is
+	// unreachable.
 	for len(frontier) > 0 {
-		for node := range frontier {
-			// if we can reach the node, add it to the reached set and add its
-			// (previously unchecked) children to the frontier
-			if node.GetMark(node, nil) == MarkTrue {
-				reached[node] = true
-				node.Mark = MarkTrue
-				for _, child := range node.Children {
-					if !reached[child] && !tried[child] {
-						frontier[child] = true
-					}
-				}
-			}
+		node := frontier[0]
+		frontier = frontier[1:]
 
-			// get this node out of my sight
-			delete(frontier, node)
-			tried[node] = true
+		for _, child := range node.Children {
+			if !reached[child] && child.peekMark() == MarkTrue {
+				reached[child] = true
+				child.Mark = MarkTrue
+				frontier = append(frontier, child)
+			}
 		}
 	}
 
@@ -16,7 +16,8 @@ type Mark int
 
 // NodeType determines how a node approaches GetMark(). And nodes return
 // MarkTrue only if all of their parents do, Or nodes return MarkTrue if any of
-// their parents do, and Root nodes always return MarkTrue.
+// their parents do, Count nodes return MarkTrue if at least Threshold of their
+// parents do, and Root nodes always return MarkTrue.
 //
 // Technically an And node with no parents functions the same as a Root node,
 // and an Or node with no parents always returns MarkFalse.
@@ -35,6 +36,7 @@ const (
 	RootType NodeType = iota
 	AndType
 	OrType
+	CountType
 )
 
 // A Node is a single point in the directed graph.
@@ -46,6 +48,8 @@ type Node struct {
 	Mark     Mark
 	Parents  []*Node
 	Children []*Node
+
+	Threshold int // only used by Count nodes
 }
 
 // NewNode returns a new unconnected graph node, not yet part of any graph.
@@ -68,6 +72,8 @@ func NewNode(name string, nodeType NodeType, isStep bool) *Node {
 		n.GetMark = getAndMark
 	case OrType:
 		n.GetMark = getOrMark
+	case CountType:
+		n.GetMark = getCountMark
 	default:
 		panic("unknown node type for node " + name)
 	}
@@ -162,6 +168,66 @@ func getOrMark(n *Node, path *list.List) Mark {
 	return n.Mark
 }
 
+func getCountMark(n *Node, path *list.List) Mark {
+	if n.Mark == MarkNone {
+		n.Mark = MarkPending
+		parentNames := make([]string, 0, n.Threshold)
+
+		// pending parents don't count, same as for an Or node
+		for _, parent := range n.Parents {
+			if parent.GetMark(parent, path) == MarkTrue {
+				parentNames = append(parentNames, parent.Name)
+				if len(parentNames) >= n.Threshold {
+					n.Mark = MarkTrue
+					break
+				}
+			}
+		}
+
+		if n.Mark == MarkPending {
+			n.Mark = MarkNone
+			return MarkFalse
+		}
+
+		if path != nil {
+			path.PushBack(n.Name + " <- " + strings.Join(parentNames, ", "))
+		}
+	}
+
+	return n.Mark
+}
+
+// returns MarkTrue iff the node is satisfied by the current marks of its
+// parents, without evaluating any of them.
+func (n *Node) peekMark() Mark {
+	switch n.Type {
+	case AndType:
+		for _, parent := range n.Parents {
+			if parent.Mark != MarkTrue {
+				return MarkFalse
+			}
+		}
+		return MarkTrue
+	case OrType:
+		for _, parent := range n.Parents {
+			if parent.Mark == MarkTrue {
+				return MarkTrue
+			}
+		}
+	case CountType:
+		count := 0
+		for _, parent := range n.Parents {
+			if parent.Mark == MarkTrue {
+				count++
+			}
+		}
+		if count >= n.Threshold {
+			return MarkTrue
+		}
+	}
+	return MarkFalse
+}
+
 // AddParents makes the given nodes parents of the node, and likewise adds this
 // node to each parent's list of children.
 func (n *Node) AddParents(parents ...*Node) {
@@ -191,10 +257,11 @@ func IsNodeInSlice(node *Node, slice []*Node) bool {
 	return false
 }
 
+// the child is only added once per parent, since removeChild only removes one
+// copy (and Explore would visit it twice).
 func addChild(child *Node, parents ...*Node) {
 	for _, parent := range parents {
 		parent.Children = append(parent.Children, child)
-		parent.Children = append(parent.Children, child)
 	}
 }
 
@@ -2,26 +2,34 @@ package graph
 
 import (
 	"fmt"
+	"math/rand"
 	"testing"
 )
 
 // helper functions
 
-var andCounter, orCounter int
+var andCounter, orCounter, countCounter int
 
-func makeAndNode() Node {
+func makeAndNode() *Node {
 	andCounter++
-	return NewAndNode(fmt.Sprintf("and%d", andCounter), false)
+	return NewNode(fmt.Sprintf("and%d", andCounter), AndType, false)
 }
 
-func makeOrNode() Node {
+func makeOrNode() *Node {
 	orCounter++
-	return NewOrNode(fmt.Sprintf("or%d", orCounter), false)
+	return NewNode(fmt.Sprintf("or%d", orCounter), OrType, false)
 }
 
-func clearMarks(nodes ...Node) {
+func makeCountNode(threshold int) *Node {
+	countCounter++
+	n := NewNode(fmt.Sprintf("count%d", countCounter), CountType, false)
+	n.Threshold = threshold
+	return n
+}
+
+func clearMarks(nodes ...*Node) {
 	for _, n := range nodes {
-		n.SetMark(MarkNone)
+		n.Mark = MarkNone
 	}
 }
 
@@ -29,44 +37,45 @@ func clearMarks(nodes ...Node) {
 
 func TestNodeName(t *testing.T) {
 	names := []string{"foo", "bar"}
-	nodes := []Node{NewAndNode(names[0], false), NewOrNode(names[1], false)}
+	nodes := []*Node{NewNode(names[0], AndType, false),
+		NewNode(names[1], OrType, false)}
 
 	for i, node := range nodes {
-		if node.Name() != names[i] {
-			t.Errorf("want %s, got %s", names[i], node.Name())
+		if node.Name != names[i] {
+			t.Errorf("want %s, got %s", names[i], node.Name)
 		}
 	}
 }
 
 func TestNodeSetMark(t *testing.T) {
-	for _, maker := range []func() Node{makeAndNode, makeOrNode} {
+	for _, maker := range []func() *Node{makeAndNode, makeOrNode} {
 		node := maker()
-		if node.PeekMark() != MarkNone {
-			t.Errorf("want %d, got %d", MarkNone, node.PeekMark())
+		if node.Mark != MarkNone {
+			t.Errorf("want %d, got %d", MarkNone, node.Mark)
 			continue
 		}
-		node.SetMark(MarkTrue)
-		if node.PeekMark() != MarkTrue {
-			t.Errorf("want %d, got %d", MarkTrue, node.PeekMark())
+		node.Mark = MarkTrue
+		if node.Mark != MarkTrue {
+			t.Errorf("want %d, got %d", MarkTrue, node.Mark)
 			continue
 		}
 	}
 }
 
 func TestNodeRelationships(t *testing.T) {
-	permutations := [][]func() Node{
-		[]func() Node{makeAndNode, makeOrNode},
-		[]func() Node{makeOrNode, makeAndNode},
+	permutations := [][]func() *Node{
+		[]func() *Node{makeAndNode, makeOrNode},
+		[]func() *Node{makeOrNode, makeAndNode},
 	}
 
 	for _, perm := range permutations {
 		n1, n2 := perm[0](), perm[1]()
 
 		// new nodes shouldn't have relationships
-		if len(n1.Parents()) > 0 {
+		if len(n1.Parents) > 0 {
 			t.Errorf("node has parents: %+v", n1)
 		}
-		if len(n1.Children()) > 0 {
+		if len(n1.Children) > 0 {
 			t.Errorf("node has children: %+v", n1)
 		}
 		if t.Failed() {
@@ -75,17 +84,17 @@ func TestNodeRelationships(t *testing.T) {
 
 		// test adding a parent
 		n1.AddParents(n2)
-		if len(n1.Parents()) == 0 {
+		if len(n1.Parents) == 0 {
 			t.Errorf("node has no parents: %+v", n1)
 		}
-		if len(n1.Children()) > 0 {
+		if len(n1.Children) > 0 {
 			t.Errorf("node has children: %+v", n1)
 		}
-		if len(n2.Parents()) > 0 {
+		if len(n2.Parents) > 0 {
 			t.Errorf("node has parents: %+v", n2)
 		}
-		if len(n2.Children()) == 0 {
-			t.Errorf("node has no children: %+v", n2)
+		if len(n2.Children) != 1 {
+			t.Errorf("node doesn't have exactly one child: %+v", n2)
 		}
 		if t.Failed() {
 			continue
@@ -93,10 +102,10 @@ func TestNodeRelationships(t *testing.T) {
 
 		// test clearing parents
 		n1.ClearParents()
-		if len(n1.Parents()) > 0 {
+		if len(n1.Parents) > 0 {
 			t.Errorf("node has parents: %+v", n1)
 		}
-		if len(n2.Children()) > 0 {
+		if len(n2.Children) > 0 {
 			t.Errorf("node has children: %+v", n2)
 		}
 	}
@@ -105,7 +114,7 @@ func TestNodeRelationships(t *testing.T) {
 // make sure nodes convert to string correctly
 func TestNodeString(t *testing.T) {
 	andName, orName := "and1", "or1"
-	and1, or1 := NewAndNode(andName, false), NewOrNode(orName, false)
+	and1, or1 := NewNode(andName, AndType, false), NewNode(orName, OrType, false)
 
 	if s := and1.String(); s != andName {
 		t.Errorf("want %s, got %s", andName, s)
@@ -120,11 +129,11 @@ func TestNodeGetMark(t *testing.T) {
 	and1, or1 := makeAndNode(), makeOrNode()
 
 	// orphan AndNodes are true
-	if mark := and1.GetMark(nil); mark != MarkTrue {
+	if mark := and1.GetMark(and1, nil); mark != MarkTrue {
 		t.Fatalf("want %d, got %d", MarkTrue, mark)
 	}
 	// orphan OrNodes are false
-	if mark := or1.GetMark(nil); mark != MarkFalse {
+	if mark := or1.GetMark(or1, nil); mark != MarkFalse {
 		t.Fatalf("want %d, got %d", MarkFalse, mark)
 	}
 
@@ -133,7 +142,7 @@ func TestNodeGetMark(t *testing.T) {
 	clearMarks(and1, or1)
 
 	// AndNodes need all parents to succeed
-	if mark := and1.GetMark(nil); mark != MarkFalse {
+	if mark := and1.GetMark(and1, nil); mark != MarkFalse {
 		t.Fatalf("want %d, got %d", MarkFalse, mark)
 	}
 
@@ -142,12 +151,12 @@ func TestNodeGetMark(t *testing.T) {
 	clearMarks(and1, or1, and2)
 
 	// OrNodes need one
-	if mark := or1.GetMark(nil); mark != MarkFalse {
+	if mark := or1.GetMark(or1, nil); mark != MarkFalse {
 		t.Fatalf("want %d, got %d", MarkFalse, mark)
 	}
 	// make sure the OrNode gets the same results by peeking
-	or1.SetMark(MarkNone)
-	if mark := or1.GetMark(nil); mark != MarkFalse {
+	or1.Mark = MarkNone
+	if mark := or1.GetMark(or1, nil); mark != MarkFalse {
 		t.Fatalf("want %d, got %d", MarkFalse, mark)
 	}
 
@@ -158,16 +167,16 @@ func TestNodeGetMark(t *testing.T) {
 	clearMarks(and1, or1, and2, or2)
 
 	// and only one
-	if mark := or1.GetMark(nil); mark != MarkTrue {
+	if mark := or1.GetMark(or1, nil); mark != MarkTrue {
 		t.Fatalf("want %d, got %d", MarkTrue, mark)
 	}
 	// make sure the OrNode gets the same results by peeking
-	or1.SetMark(MarkNone)
-	if mark := or1.GetMark(nil); mark != MarkTrue {
+	or1.Mark = MarkNone
+	if mark := or1.GetMark(or1, nil); mark != MarkTrue {
 		t.Fatalf("want %d, got %d", MarkTrue, mark)
 	}
 	// and now the AndNode should be satisfied
-	if mark := and1.GetMark(nil); mark != MarkTrue {
+	if mark := and1.GetMark(and1, nil); mark != MarkTrue {
 		t.Fatalf("want %d, got %d", MarkTrue, mark)
 	}
 
@@ -181,10 +190,181 @@ func TestNodeGetMark(t *testing.T) {
 	or1.AddParents(or2)
 	or2.AddParents(or1)
 	clearMarks(and1, and2, or1, or2)
-	if mark := and1.GetMark(nil); mark != MarkFalse {
+	if mark := and1.GetMark(and1, nil); mark != MarkFalse {
+		t.Fatalf("want %d, got %d", MarkFalse, mark)
+	}
+	if mark := or1.GetMark(or1, nil); mark != MarkFalse {
+		t.Fatalf("want %d, got %d", MarkFalse, mark)
+	}
+}
+
+func TestCountNodeGetMark(t *testing.T) {
+	count := makeCountNode(2)
+	and1, and2, or1 := makeAndNode(), makeAndNode(), makeOrNode()
+	count.AddParents(and1, or1)
+
+	// one true parent isn't enough
+	if mark := count.GetMark(count, nil); mark != MarkFalse {
 		t.Fatalf("want %d, got %d", MarkFalse, mark)
 	}
-	if mark := or1.GetMark(nil); mark != MarkFalse {
+
+	// but two is
+	count.AddParents(and2)
+	clearMarks(count, and1, and2, or1)
+	if mark := count.GetMark(count, nil); mark != MarkTrue {
+		t.Fatalf("want %d, got %d", MarkTrue, mark)
+	}
+
+	// and loops don't count
+	count.ClearParents()
+	or1.AddParents(count)
+	count.AddParents(and1, or1)
+	clearMarks(count, and1, or1)
+	if mark := count.GetMark(count, nil); mark != MarkFalse {
 		t.Fatalf("want %d, got %d", MarkFalse, mark)
 	}
+	if count.Mark != MarkNone || or1.Mark != MarkNone {
+		t.Fatalf("pending marks left over: %d, %d", count.Mark, or1.Mark)
+	}
+}
+
+func TestExploreCount(t *testing.T) {
+	g := New()
+	count := makeCountNode(2)
+	parents := []*Node{makeOrNode(), makeOrNode(), makeOrNode()}
+	g.AddNodes(append(parents, count)...)
+	count.AddParents(parents...)
+
+	reached := g.Explore(make(map[*Node]bool), parents[:1])
+	if reached[count] {
+		t.Fatal("count node reached with one parent")
+	}
+	reached = g.Explore(reached, parents[2:])
+	if !reached[count] {
+		t.Fatal("count node not reached with two parents")
+	}
+}
+
+// the way Explore used to work before user-003: evaluating each unreached
+// child with GetMark, which recursively checks all of its ancestors. kept
+// here to check the forward version against.
+func exploreByGetMark(g Graph, start map[*Node]bool,
+	add []*Node) map[*Node]bool {
+	g.ClearMarks()
+	reached := make(map[*Node]bool, len(start))
+	for node := range start {
+		reached[node] = true
+		node.Mark = MarkTrue
+	}
+
+	frontier := make([]*Node, 0)
+	queued := make(map[*Node]bool)
+	for _, node := range add {
+		reached[node] = true
+		node.Mark = MarkTrue
+		for _, child := range node.Children {
+			if !reached[child] && !queued[child] {
+				frontier = append(frontier, child)
+				queued[child] = true
+			}
+		}
+	}
+
+	tried := make(map[*Node]bool)
+	for len(frontier) > 0 {
+		node := frontier[0]
+		if node.GetMark(node, nil) == MarkTrue {
+			reached[node] = true
+			node.Mark = MarkTrue
+			for _, child := range node.Children {
+				if !reached[child] && !tried[child] && !queued[child] {
+					frontier = append(frontier, child)
+					queued[child] = true
+				}
+			}
+		}
+		frontier = frontier[1:]
+		delete(queued, node)
+		tried[node] = true
+	}
+
+	return reached
+}
+
+// returns a random graph of and/or nodes, loops and all, where every node but
+// the first has at least one parent.
+func makeRandomGraph(src *rand.Rand) (Graph, []*Node) {
+	g := New()
+	nodes := make([]*Node, 3+src.Intn(10))
+	for i := range nodes {
+		nodeType := AndType
+		if src.Intn(2) == 0 {
+			nodeType = OrType
+		}
+		nodes[i] = NewNode(fmt.Sprintf("node%d", i), nodeType, false)
+		g.AddNodes(nodes[i])
+	}
+	for i, node := range nodes[1:] {
+		for len(node.Parents) == 0 || src.Intn(2) == 0 {
+			parent := nodes[src.Intn(len(nodes))]
+			if parent != nodes[i+1] && !IsNodeInSlice(parent, node.Parents) {
+				node.AddParents(parent)
+			}
+			if len(node.Parents) == len(nodes)-1 {
+				break
+			}
+		}
+	}
+	return g, nodes
+}
+
+// the forward version of Explore has to reach the same nodes as the old one
+func TestExploreMatchesGetMark(t *testing.T) {
+	src := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		g, nodes := makeRandomGraph(src)
+		want := exploreByGetMark(g, make(map[*Node]bool), nodes[:1])
+		got := g.Explore(make(map[*Node]bool), nodes[:1])
+		for _, node := range nodes {
+			if got[node] != want[node] {
+				t.Fatalf("graph %d: %s: want reached %v, got %v",
+					i, node.Name, want[node], got[node])
+			}
+			if got[node] != (node.Mark == MarkTrue) {
+				t.Fatalf("graph %d: %s: mark doesn't match", i, node.Name)
+			}
+		}
+
+		// and again when starting from the result of a previous call
+		more := nodes[src.Intn(len(nodes)):]
+		want = exploreByGetMark(g, want, more[:1])
+		got = g.Explore(got, more[:1])
+		for _, node := range nodes {
+			if got[node] != want[node] {
+				t.Fatalf("graph %d, second call: %s: want reached %v, got %v",
+					i, node.Name, want[node], got[node])
+			}
+		}
+	}
+}
+
+// the one difference: an and node with no parents used to count as reached
+// whenever a child asked for it, but now only nodes that are reached (or
+// given) count, so it has to be given explicitly.
+func TestExploreParentlessAnd(t *testing.T) {
+	g := New()
+	start, free, child := NewNode("start", AndType, false),
+		NewNode("free", AndType, false), NewNode("child", AndType, false)
+	g.AddNodes(start, free, child)
+	child.AddParents(start, free)
+
+	if !exploreByGetMark(g, make(map[*Node]bool), []*Node{start})[child] {
+		t.Error("old explore didn't reach child")
+	}
+	if g.Explore(make(map[*Node]bool), []*Node{start})[child] {
+		t.Error("child reached without free")
+	}
+	if !g.Explore(make(map[*Node]bool), []*Node{start, free})[child] {
+		t.Error("child not reached with free")
+	}
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/prenode"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+// in keysanity mode, these chests become item slots, and the keys normally in
+// them become items in the pool. small keys are named after where they're
+// normally found ("d1 key B"), but any of a dungeon's keys opens any of its
+// doors (see prenode/dungeons.go), and keys stay in their own dungeons.
+//
+// this isn't every key. key falls aren't included, since they all share the
+// same treasure data, and the chests in d3-d8 aren't either, since only the
+// d0-d2 chest addresses are known so far. keys from those stay where they are.
+var keysanityChests = map[string]string{
+	"d0 key chest":       "d0 small key",
+	"d1 key chest":       "d1 key B",
+	"d1 boss key chest":  "d1 boss key",
+	"d2 bomb key chest":  "d2 key B",
+	"d2 blade key chest": "d2 key C",
+	"d2 boss key chest":  "d2 boss key",
+}
+
+// matches the names of small key and boss key nodes
+var (
+	smallKeyRegexp = regexp.MustCompile(`^d\d (small key|key [A-Z])$`)
+	bossKeyRegexp  = regexp.MustCompile(`^d\d boss key$`)
+	dungeonRegexp  = regexp.MustCompile(`^d\d `)
+)
+
+// changes the prenode and item maps so that keysanity chests are slots and
+// their keys are items.
+func applyKeysanity(prenodes, items map[string]*prenode.Prenode) {
+	for chest, key := range keysanityChests {
+		makeSlot(prenodes, chest)
+		prenodes[key] = prenode.Root()
+		items[key] = prenodes[key]
+	}
+}
+
+// returns the treasure that the named item node represents. most items have
+// the same name as their treasure, but keys are named by dungeon and door, and
+// filler items are named by dungeon. ring treasures only differ in which ring
+// they give, so any ring gets the first one here; see seedTreasure for the one
+// it actually ends up with.
+func itemTreasure(name string) *rom.Treasure {
+	if treasureName, ok := fillerTreasures[name]; ok {
+		return rom.Treasures[treasureName]
+	}
+	switch {
+	case smallKeyRegexp.MatchString(name):
+		return rom.Treasures["chest small key"]
+	case bossKeyRegexp.MatchString(name):
+		return rom.Treasures["chest boss key"]
+	case bombchusRegexp.MatchString(name):
+		return rom.Treasures["bombchus"]
+	case isRingItem(name):
+		return rom.Treasures["find expert's ring"]
+	}
+	return rom.Treasures[name]
+}
+
+// returns true iff the named item only works in its own dungeon: small keys,
+// boss keys, maps, and compasses.
+func isDungeonItem(name string) bool {
+	return smallKeyRegexp.MatchString(name) ||
+		bossKeyRegexp.MatchString(name) || mapCompassRegexp.MatchString(name)
+}
+
+// returns true iff the item is a dungeon item that can't go in the given slot
+// because the slot is in a different dungeon.
+func isOutOfDungeon(item, slot string) bool {
+	return isDungeonItem(item) &&
+		dungeonRegexp.FindString(item) != dungeonRegexp.FindString(slot)
+}
+
+// places the dungeon items from the item list in slots in their own dungeons,
+// before anything else is placed. this is done by assumed fill (see
+// assumed.go), since searching for places to put keys along with everything
+// else takes forever. placed items and their slots are moved from the unused
+// lists to the used lists.
+func placeDungeonItems(g graph.Graph, start []*graph.Node,
+	itemList, usedItems, slotList, usedSlots *list.List) error {
+	itemElems := make([]*list.Element, 0)
+	for e := itemList.Front(); e != nil; e = e.Next() {
+		if isDungeonItem(e.Value.(*graph.Node).Name) {
+			itemElems = append(itemElems, e)
+		}
+	}
+
+	for _, itemElem := range itemElems {
+		// put the item in the first slot that's reachable when assuming all
+		// the items that haven't been placed yet
+		item := itemList.Remove(itemElem).(*graph.Node)
+		reached := g.Explore(make(map[*graph.Node]bool),
+			append(listNodes(itemList), start...))
+
+		var slotElem *list.Element
+		for e := slotList.Front(); e != nil; e = e.Next() {
+			if !reached[e.Value.(*graph.Node)] {
+				continue
+			}
+			if skip, _ := shouldSkipItem(item, e.Value.(*graph.Node),
+				false); skip {
+				continue
+			}
+
+			// small keys also can't go where using keys on the wrong doors
+			// could lock them away
+			if smallKeyRegexp.MatchString(item.Name) {
+				item.AddParents(e.Value.(*graph.Node))
+				g.Explore(make(map[*graph.Node]bool),
+					append(listNodes(itemList), start...))
+				err := canKeySoftlock(g, listNodes(itemList))
+				item.ClearParents()
+				if err != nil {
+					continue
+				}
+			}
+
+			slotElem = e
+			break
+		}
+		if slotElem == nil {
+			return fmt.Errorf("no reachable slot for %s", item)
+		}
+
+		slot := slotList.Remove(slotElem).(*graph.Node)
+		item.AddParents(slot)
+		usedItems.PushBack(item)
+		usedSlots.PushBack(slot)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+func TestKeysanity(t *testing.T) {
+	start, goal := []string{"horon village"}, []string{"done"}
+	prenodes, items := settingsPrenodes(&Settings{Keysanity: true}, &Choices{})
+	r := newRouteFromPrenodes(start, prenodes, items)
+
+	for chest, key := range keysanityChests {
+		if r.Slots[chest] == nil {
+			t.Errorf("%s is not a slot in keysanity mode", chest)
+		}
+		if r.Items[key] == nil {
+			t.Errorf("%s is not an item in keysanity mode", key)
+		}
+	}
+
+	usedItems, usedSlots, err := findAssumedRoute(
+		rand.New(rand.NewSource(0)), r, start, goal, []string{}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keys have to be in chests in their own dungeons
+	se := usedSlots.Front()
+	for ie := usedItems.Front(); ie != nil; ie = ie.Next() {
+		item, slot := ie.Value.(*graph.Node).Name, se.Value.(*graph.Node).Name
+		if isOutOfDungeon(item, slot) {
+			t.Errorf("%s placed outside its dungeon in %s", item, slot)
+		}
+		if !rom.ItemSlots[slot].CanHold(itemTreasure(item)) {
+			t.Errorf("%s placed in non-chest slot %s", item, slot)
+		}
+		se = se.Next()
+	}
+
+	reached := r.Graph.Explore(
+		make(map[*graph.Node]bool), []*graph.Node{r.Graph["horon village"]})
+	if !reached[r.Graph["done"]] {
+		t.Error("goal not reachable in keysanity route")
+	}
+}
+
+func TestItemTreasure(t *testing.T) {
+	for name, want := range map[string]string{
+		"d0 small key": "chest small key",
+		"d2 key C":     "chest small key",
+		"d1 boss key":  "chest boss key",
+		"sword L-1":    "sword L-1",
+	} {
+		if got := rom.TreasureName(itemTreasure(name)); got != want {
+			t.Errorf("treasure for %s: want %s, got %s", name, want, got)
+		}
+	}
+}
@@ -1,13 +1,21 @@
 package main
 
 import (
+	"container/list"
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math/rand"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/patch"
+	"github.com/jangler/oos-randomizer/prenode"
 	"github.com/jangler/oos-randomizer/rom"
 )
 
@@ -20,6 +28,14 @@ func checkNumArgs(op string, expected int) {
 	}
 }
 
+func checkNumArgsRange(op string, min, max int) {
+	if flag.NArg() < min || flag.NArg() > max {
+		log.Printf("%s takes %d to %d argument(s); got %d",
+			op, min, max, flag.NArg())
+		os.Exit(2)
+	}
+}
+
 func main() {
 	// init flags
 	flagGoal := flag.String("goal", "done",
@@ -29,10 +45,52 @@ func main() {
 	flagMaxlen := flag.Int("maxlen", -1,
 		"if >= 0, maximum number of slotted items in the route")
 	flagDryrun := flag.Bool(
-		"dryrun", false, "don't write an output file for any operation")
+		"dryrun", false,
+		"don't write an output file for any operation (except -spoiler)")
 	flagDevcmd := flag.String("devcmd", "", "if given, run developer command")
+	flagSeed := flag.String("seed", "",
+		"hex seed for randomization; if empty, a random seed is used")
+	flagAlgorithm := flag.String("algorithm", "backtrack",
+		"item placement algorithm: backtrack or assumed")
+	flagSpoiler := flag.String("spoiler", "",
+		"if given, write a spoiler log to this file (plain text if it ends "+
+			"in .txt, JSON otherwise)")
+	flagPatch := flag.String("patch", "",
+		"if given, write an ips or bps patch instead of a full ROM")
+	flagKeysanity := flag.Bool("keysanity", false,
+		"shuffle dungeon keys within their dungeons (d0-d2 chests only, so "+
+			"dryrun only for now)")
+	flagChests := flag.Bool("chests", false,
+		"shuffle the contents of other dungeon chests (d0-d2 only, so dryrun "+
+			"only for now)")
+	flagRings := flag.String("rings", strings.Join(defaultRings, ","),
+		"comma-separated list of up to 4 rings to put in the pool")
+	flagSeasons := flag.Bool("seasons", false,
+		"randomize the default season of each area (logic only; dryrun only "+
+			"for now)")
+	flagEssences := flag.Int("essences", 8,
+		"number of essences needed for the maku seed (below 8 is logic only, "+
+			"so dryrun only)")
+	flagStartItems := flag.String("start-items", "",
+		"comma-separated list of items to start with (logic only; dryrun "+
+			"only for now)")
+	flagPlando := flag.String("plando", "",
+		"if given, a JSON file of slot -> item placements to keep fixed")
+	flagLogicFile := flag.String("logic-file", "",
+		"if given, load logic from this file instead of the built-in logic")
+	flagDifficulty := flag.String("logic", "casual",
+		"which tricks the logic can require: casual, hard, or glitched")
+	flagTricks := flag.String("tricks", "",
+		"comma-separated list of tricks to enable, or disable with a -")
 	flag.Parse()
 
+	// this has to happen before anything uses the logic
+	if *flagLogicFile != "" {
+		if err := loadLogic(*flagLogicFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	// perform given command (or default, randomize)
 	switch *flagDevcmd {
 	case "checkgraph":
@@ -45,6 +103,129 @@ func main() {
 				log.Print(err)
 			}
 		}
+	case "listnodes":
+		// print all the node names, for use with -goal and -forbid
+		checkNumArgs(*flagDevcmd, 0)
+
+		if err := writeNodeList(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	case "exportlogic":
+		// write the built-in logic in the format that -logic-file reads
+		checkNumArgs(*flagDevcmd, 1)
+
+		if !*flagDryrun {
+			f, err := os.Create(flag.Arg(0))
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+
+			if err := prenode.ExportLogic(f); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("wrote logic to %s", flag.Arg(0))
+		}
+	case "exportgraph":
+		// write the graph as DOT or GraphML, optionally only around a node
+		// and with a route from -plando drawn on top
+		checkNumArgsRange(*flagDevcmd, 1, 3)
+
+		node, depth := flag.Arg(1), 2
+		if flag.NArg() == 3 {
+			var err error
+			if depth, err = strconv.Atoi(flag.Arg(2)); err != nil {
+				log.Fatalf("invalid depth %q", flag.Arg(2))
+			}
+		}
+		var placed map[string]string
+		var s *Settings
+		var c *Choices
+		if *flagPlando != "" {
+			var err error
+			if placed, err = loadPlando(*flagPlando); err != nil {
+				log.Fatal(err)
+			}
+			// spoiler logs say what settings the slots were placed with
+			if s, c, err = loadSpoilerSettings(*flagPlando); err != nil {
+				log.Fatal(err)
+			}
+		}
+		if s == nil {
+			// otherwise use the flags, except for the ones that need random
+			// choices, which only a spoiler log has
+			if *flagSeasons {
+				log.Fatal("exportgraph: -seasons needs a spoiler log given " +
+					"with -plando")
+			}
+			s = &Settings{
+				Keysanity: *flagKeysanity,
+				Chests:    *flagChests,
+				Essences:  *flagEssences,
+
+				Difficulty: *flagDifficulty,
+			}
+			if *flagStartItems != "" {
+				s.StartItems = strings.Split(*flagStartItems, ",")
+			}
+			if *flagTricks != "" {
+				s.Tricks = strings.Split(*flagTricks, ",")
+			}
+			c = &Choices{Rings: strings.Split(*flagRings, ",")}
+			if err := checkRings(c.Rings); err != nil {
+				log.Fatal(err)
+			}
+			if err := checkTricks(s.Difficulty, s.Tricks); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if !*flagDryrun {
+			if err := exportGraph(flag.Arg(0), node, depth, s, c,
+				placed); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("wrote graph to %s", flag.Arg(0))
+		}
+	case "explain":
+		// show how a node is reached, or what keeps it from being reached,
+		// optionally with items placed according to a plando/spoiler file
+		checkNumArgsRange(*flagDevcmd, 1, 2)
+
+		var placed map[string]string
+		var s *Settings
+		var c *Choices
+		if flag.NArg() == 2 {
+			var err error
+			if placed, err = loadPlando(flag.Arg(1)); err != nil {
+				log.Fatal(err)
+			}
+			// spoiler logs say what settings the slots were placed with
+			if s, c, err = loadSpoilerSettings(flag.Arg(1)); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		r, start := placementRoute(s, c)
+		if err := explainNode(os.Stdout, r, start, flag.Arg(0),
+			placed); err != nil {
+			log.Fatal(err)
+		}
+	case "check":
+		// work out whether an already-randomized ROM can be beaten
+		checkNumArgs(*flagDevcmd, 1)
+
+		romData, err := readFileBytes(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		ok, err := checkSeed(os.Stdout, romData)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
 	case "pregen":
 		// auto-generate some graph nodes
 		checkNumArgs(*flagDevcmd, 1)
@@ -56,6 +237,29 @@ func main() {
 		defer f.Close()
 
 		generatePrenodes(f)
+	case "applypatch":
+		// rebuild a randomized rom from the original and a patch
+		checkNumArgs(*flagDevcmd, 3)
+
+		romData, err := readFileBytes(flag.Arg(0))
+		if err != nil {
+			log.Fatal(err)
+		}
+		patchData, err := readFileBytes(flag.Arg(1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		newData, err := patch.Apply(romData, patchData)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if !*flagDryrun {
+			if err := ioutil.WriteFile(flag.Arg(2), newData, 0666); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("wrote patched ROM to %s", flag.Arg(2))
+		}
 	case "verify":
 		checkNumArgs(*flagDevcmd, 1)
 
@@ -87,6 +291,13 @@ func main() {
 			log.Fatal(err)
 		}
 
+		// get seed, so that it can be shared
+		seed, err := getSeed(*flagSeed)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("using seed %08x", seed)
+
 		// split node params
 		goal := strings.Split(*flagGoal, ",")
 		forbid := []string{}
@@ -94,9 +305,76 @@ func main() {
 			forbid = strings.Split(*flagForbid, ",")
 		}
 
+		// check this before randomizing instead of after
+		if *flagPatch != "" && *flagPatch != "ips" && *flagPatch != "bps" {
+			log.Fatalf("no such patch format: %s", *flagPatch)
+		}
+
+		// the ROM data for these hasn't been located yet, so a ROM written
+		// with them would be logically broken
+		if *flagSeasons && !*flagDryrun {
+			log.Fatal("-seasons can only be used with -dryrun for now")
+		}
+		// and only some of the data for these is known, so a ROM written with
+		// them would only be partly shuffled
+		if *flagKeysanity && !*flagDryrun {
+			log.Fatal("-keysanity can only be used with -dryrun for now, " +
+				"since only the d0-d2 key chests are known")
+		}
+		if *flagChests && !*flagDryrun {
+			log.Fatal("-chests can only be used with -dryrun for now, " +
+				"since only the d0-d2 chests are known")
+		}
+		if *flagEssences < 1 || *flagEssences > 8 {
+			log.Fatalf("-essences must be from 1 to 8; got %d", *flagEssences)
+		}
+		if *flagEssences < 8 && !*flagDryrun {
+			log.Fatal("-essences below 8 can only be used with -dryrun for now")
+		}
+		startItems := []string{}
+		if *flagStartItems != "" {
+			startItems = strings.Split(*flagStartItems, ",")
+			if !*flagDryrun {
+				log.Fatal("-start-items can only be used with -dryrun for now")
+			}
+		}
+
+		tricks := []string{}
+		if *flagTricks != "" {
+			tricks = strings.Split(*flagTricks, ",")
+		}
+
+		var plando map[string]string
+		if *flagPlando != "" {
+			if plando, err = loadPlando(*flagPlando); err != nil {
+				log.Fatal(err)
+			}
+		}
+
 		// randomize according to params
-		if errs := randomize(romData, flag.Arg(1), []string{"horon village"},
-			goal, forbid, *flagMaxlen); errs != nil {
+		settings := &Settings{
+			Seed:   seed,
+			Goal:   goal,
+			Forbid: forbid,
+			MaxLen: *flagMaxlen,
+
+			Algorithm: *flagAlgorithm,
+			Keysanity: *flagKeysanity,
+			Chests:    *flagChests,
+			Rings:     strings.Split(*flagRings, ","),
+			Seasons:   *flagSeasons,
+			Essences:  *flagEssences,
+
+			StartItems: startItems,
+			Plando:     plando,
+
+			Difficulty: *flagDifficulty,
+			Tricks:     tricks,
+		}
+		original := append([]byte{}, romData...)
+		spoiler, errs := randomize(romData, flag.Arg(1),
+			[]string{"horon village"}, settings)
+		if errs != nil {
 			for _, err := range errs {
 				log.Print(err)
 			}
@@ -105,15 +383,19 @@ func main() {
 
 		// write to file unless it's a dry run
 		if !*flagDryrun {
-			f, err := os.Create(flag.Arg(1))
-			if err != nil {
+			if err := writeOutput(flag.Arg(1), *flagPatch,
+				original, romData); err != nil {
 				log.Fatal(err)
 			}
-			defer f.Close()
-			if _, err := f.Write(romData); err != nil {
+		}
+
+		// the spoiler is written either way, since it's the only record of
+		// choices like seasons that can't be written to the ROM yet
+		if *flagSpoiler != "" {
+			if err := writeSpoiler(*flagSpoiler, spoiler); err != nil {
 				log.Fatal(err)
 			}
-			log.Printf("wrote new ROM to %s", flag.Arg(1))
+			log.Printf("wrote spoiler log to %s", *flagSpoiler)
 		}
 	default:
 		log.Printf("no such devcmd: %s", *flagDevcmd)
@@ -121,6 +403,54 @@ func main() {
 	}
 }
 
+// loads logic from the named file, replacing the built-in logic
+func loadLogic(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := prenode.LoadLogic(f, checkLogic); err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	return nil
+}
+
+// returns an error if the given logic is missing prenodes that the program
+// refers to by name, or has slots (or chests that can become slots) that
+// aren't in the ROM.
+func checkLogic(prenodes map[string]*prenode.Prenode) error {
+	slots := make([]string, 0)
+	for name, pn := range prenodes {
+		if pn.Type == prenode.AndSlotType || pn.Type == prenode.OrSlotType {
+			slots = append(slots, name)
+		}
+	}
+	required := []string{"maku seed"}
+	for chest := range keysanityChests {
+		required, slots = append(required, chest), append(slots, chest)
+	}
+	for chest := range fillerChests {
+		required, slots = append(required, chest), append(slots, chest)
+	}
+
+	sort.Strings(required)
+	for _, name := range required {
+		if prenodes[name] == nil {
+			return fmt.Errorf("missing prenode: %s", name)
+		}
+	}
+	sort.Strings(slots)
+	for _, name := range slots {
+		if rom.ItemSlots[name] == nil {
+			return fmt.Errorf("%s: no such item slot in the ROM", name)
+		}
+	}
+
+	return nil
+}
+
 // return the contents of the names file as a slice of bytes
 func readFileBytes(filename string) ([]byte, error) {
 	f, err := os.Open(filename)
@@ -131,27 +461,151 @@ func readFileBytes(filename string) ([]byte, error) {
 	return ioutil.ReadAll(f)
 }
 
+// writes either the new rom data or a patch from the original to the new data,
+// depending on the patch format ("", "ips", or "bps").
+func writeOutput(filename, format string, original, romData []byte) error {
+	data, what := romData, "new ROM"
+	switch format {
+	case "ips":
+		var err error
+		if data, err = patch.IPS(original, romData); err != nil {
+			return err
+		}
+		what = "IPS patch"
+	case "bps":
+		data, what = patch.BPS(original, romData), "BPS patch"
+	}
+
+	if err := ioutil.WriteFile(filename, data, 0666); err != nil {
+		return err
+	}
+	log.Printf("wrote %s to %s", what, filename)
+	return nil
+}
+
+// returns the seed given by the hex string s, or a new random seed if s is
+// empty.
+func getSeed(s string) (uint32, error) {
+	if s == "" {
+		return uint32(time.Now().UnixNano()), nil
+	}
+	seed, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid seed %q: must be up to 8 hex digits", s)
+	}
+	return uint32(seed), nil
+}
+
+// Settings are the options that determine the outcome of randomization. The
+// same settings and program version always produce the same ROM.
+type Settings struct {
+	Seed   uint32   `json:"-"` // written separately, as hex
+	Goal   []string `json:"goal"`
+	Forbid []string `json:"forbid"`
+	MaxLen int      `json:"maxlen"`
+
+	Algorithm string `json:"algorithm"`
+	Keysanity bool   `json:"keysanity"`
+	Chests    bool   `json:"chests"`
+
+	Rings    []string `json:"rings"`
+	Seasons  bool     `json:"seasons"`
+	Essences int      `json:"essences"`
+
+	StartItems []string          `json:"startitems"`
+	Plando     map[string]string `json:"plando,omitempty"`
+
+	Difficulty string   `json:"difficulty"`
+	Tricks     []string `json:"tricks"` // toggles, not the enabled tricks
+}
+
+// Choices are the random decisions made for a seed, other than where items
+// go.
+type Choices struct {
+	Rings   []string          `json:"rings"`
+	Seasons map[string]string `json:"seasons,omitempty"` // area -> season
+}
+
+// makes the random choices for the given settings
+func makeChoices(src *rand.Rand, s *Settings) (*Choices, error) {
+	if err := checkRings(s.Rings); err != nil {
+		return nil, err
+	}
+	c := &Choices{Rings: s.Rings}
+	if s.Seasons {
+		c.Seasons = chooseSeasons(src)
+	}
+	return c, nil
+}
+
 // messes up rom data and writes it to a file. this also calls rom.Verify().
-func randomize(romData []byte, outFilename string,
-	start, goal, forbid []string, maxlen int) []error {
+// the returned spoiler describes the result.
+func randomize(romData []byte, outFilename string, start []string,
+	s *Settings) (*Spoiler, []error) {
 	// make sure rom data is a match first
 	if errs := rom.Verify(romData); errs != nil {
-		return errs
+		return nil, errs
 	}
 
 	// find a viable random route
-	r := NewRoute(start)
-	usedItems, usedSlots := findRoute(r, start, goal, forbid, maxlen)
+	if err := checkStartItems(s.StartItems); err != nil {
+		return nil, []error{err}
+	}
+	if err := checkTricks(s.Difficulty, s.Tricks); err != nil {
+		return nil, []error{err}
+	}
+	start = append(append([]string{}, start...), s.StartItems...)
+
+	src := rand.New(rand.NewSource(int64(s.Seed)))
+	c, err := makeChoices(src, s)
+	if err != nil {
+		return nil, []error{err}
+	}
+	rings, err := rom.RingTreasures(c.Rings)
+	if err != nil {
+		return nil, []error{err}
+	}
+	prenodes, items := settingsPrenodes(s, c)
+	r := newRouteFromPrenodes(start, prenodes, items)
+	r.Pinned = s.Plando
+	var usedItems, usedSlots *list.List
+	switch s.Algorithm {
+	case "backtrack":
+		usedItems, usedSlots, err = findRoute(src, r, start, s.Goal, s.Forbid,
+			s.MaxLen)
+	case "assumed":
+		usedItems, usedSlots, err = findAssumedRoute(src, r, start, s.Goal,
+			s.Forbid, s.MaxLen)
+	default:
+		err = fmt.Errorf("no such algorithm: %s", s.Algorithm)
+	}
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	// work out the order in which the items can be collected
+	playthrough := computePlaythrough(r.Graph, start, s.Goal,
+		usedItems, usedSlots)
+	announcePlaythrough(playthrough)
+	if c.Seasons != nil {
+		for _, area := range sortedAreas() {
+			log.Printf("%s default season: %s", area, c.Seasons[area])
+		}
+	}
 
 	// place selected treasures in slots
+	placed := make(map[string]string, usedItems.Len())
 	for usedItems.Len() > 0 {
 		slotName := usedSlots.Remove(usedSlots.Front()).(*graph.Node).Name
-		treasureName := usedItems.Remove(usedItems.Front()).(*graph.Node).Name
-		rom.ItemSlots[slotName].Treasure = rom.Treasures[treasureName]
+		itemName := usedItems.Remove(usedItems.Front()).(*graph.Node).Name
+		rom.ItemSlots[slotName].Treasure = seedTreasure(rings, itemName)
+		placed[slotName] = itemName
 	}
 
 	// do it! (but don't write anything)
-	rom.Mutate(romData)
+	if err := rom.Mutate(romData, ringMutables(rings)...); err != nil {
+		return nil, []error{err}
+	}
 
-	return nil
+	return newSpoiler(s, c, placed, playthrough), nil
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+// returns an error if any of the names isn't a node in the graph, with
+// suggestions for what might have been meant.
+func checkNodeNames(g graph.Graph, names []string) error {
+	for _, name := range names {
+		if g[name] != nil {
+			continue
+		}
+		if suggestions := suggestNodeNames(g, name); len(suggestions) > 0 {
+			return fmt.Errorf("no such node: %s (did you mean %s?)",
+				name, strings.Join(suggestions, ", or "))
+		}
+		return fmt.Errorf("no such node: %s", name)
+	}
+	return nil
+}
+
+// returns up to three node names that are close to the given one, closest
+// first.
+func suggestNodeNames(g graph.Graph, name string) []string {
+	// allow about one typo per three letters
+	maxDist := len(name)/3 + 1
+
+	type match struct {
+		name string
+		dist int
+	}
+	matches := make([]match, 0)
+	for nodeName := range g {
+		if dist := editDistance(name, nodeName); dist <= maxDist {
+			matches = append(matches, match{nodeName, dist})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	suggestions := make([]string, 0, 3)
+	for i := 0; i < len(matches) && i < 3; i++ {
+		suggestions = append(suggestions, `"`+matches[i].name+`"`)
+	}
+	return suggestions
+}
+
+// returns the levenshtein distance between the two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(first int, rest ...int) int {
+	for _, n := range rest {
+		if n < first {
+			first = n
+		}
+	}
+	return first
+}
+
+// writes the names of all prenodes, grouped by where they're declared
+func writeNodeList(w io.Writer) error {
+	groups := prenode.GetGroups()
+	groupNames := make([]string, 0, len(groups))
+	for name := range groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	for _, groupName := range groupNames {
+		if _, err := fmt.Fprintf(w, "%s:\n", groupName); err != nil {
+			return err
+		}
+		for _, name := range sortedKeys(groups[groupName]) {
+			if _, err := fmt.Fprintf(w, "\t%s\n", name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"d1 esence", "d1 essence", 1},
+		{"kitten", "sitting", 3},
+		{"flippers", "", 8},
+	} {
+		if got := editDistance(tc.a, tc.b); got != tc.want {
+			t.Errorf("%q, %q: want %d, got %d", tc.a, tc.b, tc.want, got)
+		}
+	}
+}
+
+func TestCheckNodeNames(t *testing.T) {
+	start := []string{"horon village"}
+	r := NewRoute(start)
+	_, _, err := findRoute(rand.New(rand.NewSource(0)), r, start,
+		[]string{"d1 esence"}, []string{}, -1)
+	if err == nil || !strings.Contains(err.Error(), `"d1 essence"`) {
+		t.Errorf("want suggestion for d1 essence, got %v", err)
+	}
+
+	_, _, err = findAssumedRoute(rand.New(rand.NewSource(0)), r, start,
+		[]string{"done"}, []string{"xyzzy"}, -1)
+	if err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("want error without suggestions, got %v", err)
+	}
+}
+
+func TestPrenodeGroups(t *testing.T) {
+	// every declared prenode should be in exactly one group. (generated ones
+	// can replace declared ones.)
+	seen := make(map[string]string)
+	for groupName, group := range prenode.GetGroups() {
+		if groupName == "generated" {
+			continue
+		}
+		for name := range group {
+			if other, ok := seen[name]; ok {
+				t.Errorf("%s in both %s and %s", name, other, groupName)
+			}
+			seen[name] = groupName
+		}
+	}
+	if len(seen) != len(prenode.GetNonGenerated()) {
+		t.Errorf("want %d prenodes in groups, got %d",
+			len(prenode.GetNonGenerated()), len(seen))
+	}
+
+	b := new(bytes.Buffer)
+	if err := writeNodeList(b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "holodrum:\n") ||
+		!strings.Contains(b.String(), "\td1 essence\n") {
+		t.Error("node list missing groups or nodes")
+	}
+}
+
+func TestCheckLogic(t *testing.T) {
+	// the built-in logic is fine
+	prenodes := prenode.GetAll()
+	if err := checkLogic(prenodes); err != nil {
+		t.Fatal(err)
+	}
+
+	// but not with a slot the ROM doesn't have
+	prenodes["fake slot"] = prenode.AndSlot("horon village")
+	if err := checkLogic(prenodes); err == nil {
+		t.Error("no error for unknown slot")
+	}
+	delete(prenodes, "fake slot")
+
+	// or without nodes the code looks up by name
+	for _, name := range []string{"maku seed", "d1 key chest", "d1 map chest"} {
+		pn := prenodes[name]
+		delete(prenodes, name)
+		if err := checkLogic(prenodes); err == nil {
+			t.Errorf("no error for missing %s", name)
+		}
+		prenodes[name] = pn
+	}
+}
@@ -0,0 +1,191 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+)
+
+// BPS encodes the target as a series of actions that read from the source,
+// the patch, or data that's already been written. Unlike IPS, it includes
+// CRC32 checksums of the source and target, so a patch applied to the wrong
+// source is rejected.
+
+const bpsHeader = "BPS1"
+
+// BPS actions
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// BPS returns a BPS patch that changes source into target.
+func BPS(source, target []byte) []byte {
+	buf := bytes.NewBufferString(bpsHeader)
+	writeVarint(buf, uint64(len(source)))
+	writeVarint(buf, uint64(len(target)))
+	writeVarint(buf, 0) // no metadata
+
+	// alternate between runs of data that match the source at the same
+	// offset, and runs of new data
+	for i := 0; i < len(target); {
+		start := i
+		if i < len(source) && source[i] == target[i] {
+			for i < len(target) && i < len(source) && source[i] == target[i] {
+				i++
+			}
+			writeVarint(buf, uint64(i-start-1)<<2|bpsSourceRead)
+		} else {
+			for i < len(target) && (i >= len(source) || source[i] != target[i]) {
+				i++
+			}
+			writeVarint(buf, uint64(i-start-1)<<2|bpsTargetRead)
+			buf.Write(target[start:i])
+		}
+	}
+
+	binary.Write(buf, binary.LittleEndian, crc32.ChecksumIEEE(source))
+	binary.Write(buf, binary.LittleEndian, crc32.ChecksumIEEE(target))
+	binary.Write(buf, binary.LittleEndian, crc32.ChecksumIEEE(buf.Bytes()))
+
+	return buf.Bytes()
+}
+
+// ApplyBPS applies a BPS patch to the source data and returns the result. It
+// returns an error if any of the patch's checksums don't match.
+func ApplyBPS(source, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(bpsHeader)) {
+		return nil, errors.New("not a BPS patch")
+	}
+	if len(patch) < len(bpsHeader)+12 {
+		return nil, errors.New("truncated BPS patch")
+	}
+
+	// check checksums first
+	footer := patch[len(patch)-12:]
+	sourceCRC := binary.LittleEndian.Uint32(footer[0:])
+	targetCRC := binary.LittleEndian.Uint32(footer[4:])
+	patchCRC := binary.LittleEndian.Uint32(footer[8:])
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != patchCRC {
+		return nil, errors.New("BPS patch is corrupt (checksum mismatch)")
+	}
+	if crc32.ChecksumIEEE(source) != sourceCRC {
+		return nil, errors.New(
+			"BPS patch is for a different ROM (source checksum mismatch)")
+	}
+
+	r := bytes.NewReader(patch[len(bpsHeader) : len(patch)-12])
+	sourceSize, err := readVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	targetSize, err := readVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	metadataSize, err := readVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if sourceSize != uint64(len(source)) {
+		return nil, fmt.Errorf("BPS patch expects %d source bytes; got %d",
+			sourceSize, len(source))
+	}
+	if _, err := r.Seek(int64(metadataSize), 1); err != nil {
+		return nil, err
+	}
+
+	target := make([]byte, targetSize)
+	var outOffset, sourceOffset, targetOffset int
+	for r.Len() > 0 {
+		data, err := readVarint(r)
+		if err != nil {
+			return nil, err
+		}
+		length := int(data>>2) + 1
+		if outOffset+length > len(target) {
+			return nil, errors.New("BPS action writes past end of target")
+		}
+
+		switch data & 3 {
+		case bpsSourceRead:
+			if outOffset+length > len(source) {
+				return nil, errors.New("BPS action reads past end of source")
+			}
+			copy(target[outOffset:], source[outOffset:outOffset+length])
+		case bpsTargetRead:
+			if _, err := r.Read(target[outOffset : outOffset+length]); err != nil {
+				return nil, err
+			}
+		case bpsSourceCopy, bpsTargetCopy:
+			offset, err := readVarint(r)
+			if err != nil {
+				return nil, err
+			}
+			delta := int(offset >> 1)
+			if offset&1 != 0 {
+				delta = -delta
+			}
+
+			if data&3 == bpsSourceCopy {
+				sourceOffset += delta
+				if sourceOffset < 0 || sourceOffset+length > len(source) {
+					return nil, errors.New("BPS source copy out of range")
+				}
+				copy(target[outOffset:], source[sourceOffset:sourceOffset+length])
+				sourceOffset += length
+			} else {
+				targetOffset += delta
+				if targetOffset < 0 || targetOffset >= outOffset {
+					return nil, errors.New("BPS target copy out of range")
+				}
+				// byte by byte, since the ranges may overlap
+				for i := 0; i < length; i++ {
+					target[outOffset+i] = target[targetOffset]
+					targetOffset++
+				}
+			}
+		}
+		outOffset += length
+	}
+
+	if crc32.ChecksumIEEE(target) != targetCRC {
+		return nil, errors.New("BPS target checksum mismatch")
+	}
+	return target, nil
+}
+
+// write a number in BPS's variable-length encoding
+func writeVarint(buf *bytes.Buffer, n uint64) {
+	for {
+		x := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			buf.WriteByte(0x80 | x)
+			return
+		}
+		buf.WriteByte(x)
+		n--
+	}
+}
+
+// read a number in BPS's variable-length encoding
+func readVarint(r *bytes.Reader) (uint64, error) {
+	var data, shift uint64 = 0, 1
+	for {
+		x, err := r.ReadByte()
+		if err != nil {
+			return 0, errors.New("truncated BPS number")
+		}
+		data += uint64(x&0x7f) * shift
+		if x&0x80 != 0 {
+			return data, nil
+		}
+		shift <<= 7
+		data += shift
+	}
+}
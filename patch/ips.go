@@ -0,0 +1,119 @@
+package patch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// IPS is a simple format: a header, then records of (offset, size, data),
+// then a footer. It has no way to check that it's applied to the right data.
+
+const (
+	ipsHeader  = "PATCH"
+	ipsFooter  = "EOF"
+	ipsMaxSize = 0xffff
+	ipsMaxAddr = 0xffffff
+	ipsEOFAddr = 0x454f46 // record at this offset would look like the footer
+)
+
+// IPS returns an IPS patch that changes source into target.
+func IPS(source, target []byte) ([]byte, error) {
+	if len(target) > ipsMaxAddr+1 {
+		return nil, fmt.Errorf("target too large for IPS: %d bytes",
+			len(target))
+	}
+
+	buf := bytes.NewBufferString(ipsHeader)
+	for i := 0; i < len(target); {
+		if i < len(source) && source[i] == target[i] {
+			i++
+			continue
+		}
+
+		// a record can't start at an offset that reads as "EOF", so start it
+		// one byte early instead
+		start := i
+		if start == ipsEOFAddr {
+			start--
+		}
+
+		// extend the record until the data matches again
+		end := i + 1
+		for end < len(target) && end-start < ipsMaxSize &&
+			(end >= len(source) || source[end] != target[end]) {
+			end++
+		}
+
+		buf.Write([]byte{byte(start >> 16), byte(start >> 8), byte(start),
+			byte((end - start) >> 8), byte(end - start)})
+		buf.Write(target[start:end])
+		i = end
+	}
+	buf.WriteString(ipsFooter)
+
+	// truncation extension: if the target is shorter, write its length
+	if len(target) < len(source) {
+		buf.Write([]byte{byte(len(target) >> 16), byte(len(target) >> 8),
+			byte(len(target))})
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ApplyIPS applies an IPS patch to the source data and returns the result.
+func ApplyIPS(source, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, []byte(ipsHeader)) {
+		return nil, errors.New("not an IPS patch")
+	}
+	target := append([]byte{}, source...)
+
+	p := patch[len(ipsHeader):]
+	for {
+		if len(p) < 3 {
+			return nil, errors.New("IPS patch ends without footer")
+		}
+		if string(p[:3]) == ipsFooter {
+			p = p[3:]
+			break
+		}
+		if len(p) < 5 {
+			return nil, errors.New("truncated IPS record")
+		}
+		addr := int(p[0])<<16 | int(p[1])<<8 | int(p[2])
+		size := int(p[3])<<8 | int(p[4])
+		p = p[5:]
+
+		var data []byte
+		if size == 0 {
+			// run-length encoded record
+			if len(p) < 3 {
+				return nil, errors.New("truncated IPS RLE record")
+			}
+			data = bytes.Repeat(p[2:3], int(p[0])<<8|int(p[1]))
+			p = p[3:]
+		} else {
+			if len(p) < size {
+				return nil, errors.New("truncated IPS record")
+			}
+			data = p[:size]
+			p = p[size:]
+		}
+
+		if addr+len(data) > len(target) {
+			target = append(target,
+				make([]byte, addr+len(data)-len(target))...)
+		}
+		copy(target[addr:], data)
+	}
+
+	// truncation extension
+	if len(p) >= 3 {
+		size := int(p[0])<<16 | int(p[1])<<8 | int(p[2])
+		if size < len(target) {
+			target = target[:size]
+		}
+	}
+
+	return target, nil
+}
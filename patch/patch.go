@@ -0,0 +1,21 @@
+// Package patch creates and applies IPS and BPS patches, so that randomized
+// ROMs can be distributed as the difference from the original instead of as a
+// whole ROM.
+package patch
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Apply applies an IPS or BPS patch to the source data, depending on the
+// patch's header, and returns the patched data.
+func Apply(source, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, []byte(ipsHeader)):
+		return ApplyIPS(source, patch)
+	case bytes.HasPrefix(patch, []byte(bpsHeader)):
+		return ApplyBPS(source, patch)
+	}
+	return nil, errors.New("unknown patch format")
+}
@@ -0,0 +1,81 @@
+package patch
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+// returns a pair of random byte slices with some differences between them
+func makeTestData(size, changes int) (source, target []byte) {
+	src := rand.New(rand.NewSource(1))
+	source = make([]byte, size)
+	src.Read(source)
+	target = append([]byte{}, source...)
+	for i := 0; i < changes; i++ {
+		target[src.Intn(size)] = byte(src.Intn(256))
+	}
+	return source, target
+}
+
+func TestIPS(t *testing.T) {
+	// big enough to have a change at the "EOF" offset
+	source, target := makeTestData(0x500000, 1000)
+	target[0x454f46] = source[0x454f46] + 1
+
+	p, err := IPS(source, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Apply(source, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result, target) {
+		t.Error("IPS round trip produced different data")
+	}
+
+	// growing and shrinking
+	for _, size := range []int{0x100, 0x300} {
+		smaller, _ := makeTestData(0x200, 0)
+		other, _ := makeTestData(size, 0)
+		p, err := IPS(smaller, other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := ApplyIPS(smaller, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(result, other) {
+			t.Errorf("IPS round trip to size %x produced different data", size)
+		}
+	}
+}
+
+func TestBPS(t *testing.T) {
+	source, target := makeTestData(0x100000, 1000)
+	target = append(target, 1, 2, 3)
+
+	p := BPS(source, target)
+	result, err := Apply(source, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result, target) {
+		t.Error("BPS round trip produced different data")
+	}
+
+	// wrong source
+	source[0]++
+	if _, err := ApplyBPS(source, p); err == nil {
+		t.Error("BPS patch applied to wrong source without error")
+	}
+	source[0]--
+
+	// corrupt patch
+	p[len(bpsHeader)+5]++
+	if _, err := ApplyBPS(source, p); err == nil {
+		t.Error("corrupt BPS patch applied without error")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+// loadPlando reads a map of slot names to item names from a JSON file. the
+// file can be either a plain object, or a spoiler log, in which case its
+// "slots" are used.
+func loadPlando(filename string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var spoiler struct {
+		Slots map[string]string `json:"slots"`
+	}
+	if err := json.Unmarshal(b, &spoiler); err == nil &&
+		spoiler.Slots != nil {
+		return spoiler.Slots, nil
+	}
+
+	var plando map[string]string
+	if err := json.Unmarshal(b, &plando); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return plando, nil
+}
+
+// reads the settings and random choices from a JSON spoiler log, so that the
+// route its slots were placed in can be rebuilt. both are nil if the file is a
+// plain plando file.
+func loadSpoilerSettings(filename string) (*Settings, *Choices, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var spoiler struct {
+		Settings *Settings `json:"settings"`
+		Choices  *Choices  `json:"choices"`
+	}
+	if err := json.Unmarshal(b, &spoiler); err != nil {
+		return nil, nil, nil // not a spoiler log
+	}
+	return spoiler.Settings, spoiler.Choices, nil
+}
+
+// moves the route's pinned items into their slots, before anything else is
+// placed. a slot that isn't part of the route can only be pinned to what's
+// already in it, so that spoiler logs can be used as plando files.
+func placePinnedItems(r *Route, itemList, usedItems, slotList,
+	usedSlots *list.List) error {
+	slotNames := make([]string, 0, len(r.Pinned))
+	for slotName := range r.Pinned {
+		slotNames = append(slotNames, slotName)
+	}
+	sort.Strings(slotNames)
+
+	for _, slotName := range slotNames {
+		itemName := r.Pinned[slotName]
+		slotElem := findNodeElem(slotList, slotName)
+		if slotElem == nil {
+			if slot, ok := rom.ItemSlots[slotName]; ok &&
+				rom.TreasureName(slot.Treasure) == itemName {
+				continue
+			}
+			return fmt.Errorf("can't pin %s: no such slot", slotName)
+		}
+		itemElem := findNodeElem(itemList, itemName)
+		if itemElem == nil {
+			return fmt.Errorf("can't pin %s: no %s in pool", slotName, itemName)
+		}
+
+		item, slot := itemElem.Value.(*graph.Node), slotElem.Value.(*graph.Node)
+		if skip, _ := shouldSkipItem(item, slot, false); skip {
+			return fmt.Errorf("can't pin %s: %s can't go there",
+				slotName, itemName)
+		}
+
+		itemList.Remove(itemElem)
+		slotList.Remove(slotElem)
+		item.AddParents(slot)
+		usedItems.PushBack(item)
+		usedSlots.PushBack(slot)
+	}
+
+	return nil
+}
+
+// returns the element of the node list with the given name, or nil
+func findNodeElem(l *list.List, name string) *list.Element {
+	for e := l.Front(); e != nil; e = e.Next() {
+		if e.Value.(*graph.Node).Name == name {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"container/list"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+func TestPlando(t *testing.T) {
+	start, goal := []string{"horon village"}, []string{"done"}
+	r := NewRoute(start)
+	r.Pinned = map[string]string{
+		"maku key fall": "flippers",
+		"star ore spot": "star ore",
+	}
+
+	for _, find := range []func(*rand.Rand, *Route, []string, []string,
+		[]string, int) (*list.List, *list.List, error){
+		findRoute, findAssumedRoute} {
+		usedItems, usedSlots, err := find(rand.New(rand.NewSource(0)), r,
+			start, goal, []string{}, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		placed := make(map[string]string)
+		se := usedSlots.Front()
+		for ie := usedItems.Front(); ie != nil; ie = ie.Next() {
+			placed[se.Value.(*graph.Node).Name] = ie.Value.(*graph.Node).Name
+			se = se.Next()
+		}
+		for slot, item := range r.Pinned {
+			if placed[slot] != item {
+				t.Errorf("want %s in %s, got %s", item, slot, placed[slot])
+			}
+		}
+
+		for e := usedItems.Front(); e != nil; e = e.Next() {
+			e.Value.(*graph.Node).ClearParents()
+		}
+	}
+}
+
+func TestBadPlando(t *testing.T) {
+	start, goal := []string{"horon village"}, []string{"done"}
+	for _, pinned := range []map[string]string{
+		{"d0 sword chest": "sword L-3"},    // no such item
+		{"d9 sword chest": "flippers"},     // no such slot
+		{"star ore spot": "boomerang L-2"}, // nonzero sub ID
+	} {
+		r := NewRoute(start)
+		r.Pinned = pinned
+		if _, _, err := findRoute(rand.New(rand.NewSource(0)), r, start, goal,
+			[]string{}, -1); err == nil {
+			t.Errorf("no error for %v", pinned)
+		}
+	}
+}
+
+func TestLoadPlando(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plando")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, s := range []string{
+		`{"d0 sword chest": "flippers"}`,
+		`{"seed": "00000000", "slots": {"d0 sword chest": "flippers"}}`,
+	} {
+		filename := filepath.Join(dir, "plando.json")
+		if err := ioutil.WriteFile(filename, []byte(s), 0666); err != nil {
+			t.Fatal(err)
+		}
+		plando, err := loadPlando(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plando) != 1 || plando["d0 sword chest"] != "flippers" {
+			t.Errorf("bad plando from %s: %v", s, plando)
+		}
+	}
+
+	// spoiler logs also have the settings the slots were placed with
+	filename := filepath.Join(dir, "spoiler.json")
+	if err := ioutil.WriteFile(filename, []byte(`{"settings": `+
+		`{"keysanity": true}, "slots": {}}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if s, _, err := loadSpoilerSettings(filename); err != nil {
+		t.Fatal(err)
+	} else if s == nil || !s.Keysanity {
+		t.Errorf("bad settings from spoiler: %+v", s)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"container/list"
+	"log"
+	"sort"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+// A Placement is an item in a slot, as listed in a playthrough.
+type Placement struct {
+	Item     string `json:"item"`
+	Slot     string `json:"slot"`
+	Required bool   `json:"required"` // needed to reach the goal nodes
+}
+
+// A Sphere is the set of placements that become reachable after collecting
+// every item in the previous spheres. Sphere 0 is what's reachable from the
+// start with no items.
+type Sphere []Placement
+
+// computePlaythrough groups the given parallel lists of item and slot nodes
+// into spheres, and marks each placement as required or optional for reaching
+// all the nodes in goal. The graph's relationships are the same afterward, but
+// its marks are not.
+func computePlaythrough(g graph.Graph, start, goal []string,
+	usedItems, usedSlots *list.List) []Sphere {
+	// map slots to the items in them
+	slotItems := make(map[*graph.Node]*graph.Node, usedItems.Len())
+	se := usedSlots.Front()
+	for ie := usedItems.Front(); ie != nil; ie = ie.Next() {
+		slotItems[se.Value.(*graph.Node)] = ie.Value.(*graph.Node)
+		se = se.Next()
+	}
+
+	startNodes := make([]*graph.Node, len(start))
+	for i, name := range start {
+		startNodes[i] = g[name]
+	}
+
+	// make all the items unreachable until they're collected
+	for _, item := range slotItems {
+		item.ClearParents()
+	}
+
+	// explore one sphere at a time, collecting everything reachable in the
+	// previous sphere
+	spheres := make([]Sphere, 0)
+	collected := make(map[*graph.Node]bool)
+	reached := g.Explore(make(map[*graph.Node]bool), startNodes)
+	for {
+		sphere := make(Sphere, 0)
+		newItems := make([]*graph.Node, 0)
+		for slot, item := range slotItems {
+			if reached[slot] && !collected[item] {
+				sphere = append(sphere, Placement{Item: item.Name, Slot: slot.Name})
+				newItems = append(newItems, item)
+				collected[item] = true
+			}
+		}
+		if len(sphere) == 0 {
+			break
+		}
+
+		// sort for consistent output, since the map order is random
+		sort.Slice(sphere, func(i, j int) bool {
+			return sphere[i].Slot < sphere[j].Slot
+		})
+		sort.Slice(newItems, func(i, j int) bool {
+			return newItems[i].Name < newItems[j].Name
+		})
+
+		spheres = append(spheres, sphere)
+		reached = g.Explore(reached, newItems)
+	}
+
+	// put the items back where they were, then check which ones are required
+	for slot, item := range slotItems {
+		item.AddParents(slot)
+	}
+	for _, sphere := range spheres {
+		for i := range sphere {
+			sphere[i].Required = isItemRequired(
+				g, g[sphere[i].Item], startNodes, goal)
+		}
+	}
+
+	return spheres
+}
+
+// returns true iff some node in goal can't be reached if item is unavailable
+func isItemRequired(g graph.Graph, item *graph.Node, start []*graph.Node,
+	goal []string) bool {
+	parents := append([]*graph.Node{}, item.Parents...)
+	item.ClearParents()
+	defer item.AddParents(parents...)
+
+	reached := g.Explore(make(map[*graph.Node]bool), start)
+	for _, name := range goal {
+		if !reached[g[name]] {
+			return true
+		}
+	}
+	return false
+}
+
+// print the playthrough, sphere by sphere
+func announcePlaythrough(spheres []Sphere) {
+	log.Print("-- playthrough")
+	for i, sphere := range spheres {
+		log.Printf("sphere %d:", i)
+		for _, p := range sphere {
+			log.Print(p)
+		}
+	}
+}
+
+// String satisfies the fmt.Stringer interface.
+func (p Placement) String() string {
+	if p.Required {
+		return p.Item + " <- " + p.Slot + " (required)"
+	}
+	return p.Item + " <- " + p.Slot
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"container/list"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+func TestComputePlaythrough(t *testing.T) {
+	start, goal := []string{"horon village"}, []string{"done"}
+	r := NewRoute(start)
+	g := r.Graph
+
+	// place all the items in their vanilla slots
+	usedItems, usedSlots := list.New(), list.New()
+	for _, name := range sortedSlotNames(r) {
+		item := g[rom.TreasureName(rom.ItemSlots[name].Treasure)]
+		item.AddParents(g[name])
+		usedItems.PushBack(item)
+		usedSlots.PushBack(g[name])
+	}
+
+	spheres := computePlaythrough(g, start, goal, usedItems, usedSlots)
+
+	// every placement should be in exactly one sphere
+	seen := make(map[string]bool)
+	for _, sphere := range spheres {
+		for _, p := range sphere {
+			if seen[p.Slot] {
+				t.Errorf("slot in more than one sphere: %s", p.Slot)
+			}
+			seen[p.Slot] = true
+		}
+	}
+	if len(seen) != usedSlots.Len() {
+		t.Errorf("want %d placements, got %d", usedSlots.Len(), len(seen))
+	}
+
+	// the sword is in the only slot reachable with no items, and you can't
+	// finish without it
+	if len(spheres) == 0 || len(spheres[0]) != 1 ||
+		spheres[0][0].Item != "sword L-1" || !spheres[0][0].Required {
+		t.Errorf("want required sword L-1 alone in sphere 0, got %v", spheres)
+	}
+
+	// and the graph should be left as it was
+	for e := usedItems.Front(); e != nil; e = e.Next() {
+		if len(e.Value.(*graph.Node).Parents) != 1 {
+			t.Fatalf("parents of %v altered by playthrough", e.Value)
+		}
+	}
+}
@@ -6,7 +6,7 @@ package main
 //go:generate go build
 
 // this file contains logic for automaticaly generating graph prenodes based on
-// special syntax in the keys:
+// special syntax in the keys (see prenode.NumberPrenodes):
 //
 // - if a key ends with a number, e.g. "scent tree 1", that key is added as a
 //   new Or prenode named "scent tree" in the graph, with the original key as
@@ -18,7 +18,6 @@ package main
 import (
 	"fmt"
 	"io"
-	"regexp"
 	"sort"
 	"strings"
 
@@ -36,7 +35,7 @@ func generatePrenodes(w io.Writer) error {
 	nonGeneratedPrenodes := prenode.GetNonGenerated()
 
 	// get list of generated prenodes
-	resultPrenodes := makeNumberPrenodes(nonGeneratedPrenodes)
+	resultPrenodes := prenode.NumberPrenodes(nonGeneratedPrenodes)
 
 	// consistently order map keys to minimize diffs
 	orderedKeys := make(sort.StringSlice, len(resultPrenodes))
@@ -50,8 +49,8 @@ func generatePrenodes(w io.Writer) error {
 	// build contents of generated map string
 	builder := new(strings.Builder)
 	for _, key := range orderedKeys {
-		builder.WriteString(strings.Replace(fmt.Sprintf("\t\"%s\": %#v,\n",
-			key, resultPrenodes[key]), "prenode.", "", 1))
+		builder.WriteString(fmt.Sprintf("\t\"%s\": %s,\n",
+			key, prenodeLiteral(resultPrenodes[key])))
 	}
 
 	// write out result
@@ -59,27 +58,12 @@ func generatePrenodes(w io.Writer) error {
 	return err
 }
 
-func makeNumberPrenodes(
-	maps ...map[string]*prenode.Prenode) map[string]*prenode.Prenode {
-	numberPrenodes := make(map[string]*prenode.Prenode)
-	numberRegexp := regexp.MustCompile(`(^.+) \d+$`)
-
-	for _, prenodes := range maps {
-		for key := range prenodes {
-			matches := numberRegexp.FindAllStringSubmatch(key, 1)
-			if matches != nil {
-				realKey := matches[0][1]
-				if pt, ok := numberPrenodes[realKey]; ok {
-					// sort for consistent order and minimal diffs
-					parents := sort.StringSlice(append(pt.Parents, key))
-					parents.Sort()
-					numberPrenodes[realKey] = prenode.Or(parents...)
-				} else {
-					numberPrenodes[realKey] = prenode.Or(key)
-				}
-			}
-		}
+// returns go source for the prenode. the threshold is only included for count
+// prenodes, so that adding it didn't change every line of the generated file.
+func prenodeLiteral(p *prenode.Prenode) string {
+	s := fmt.Sprintf("&Prenode{Parents: %#v, Type: %d", p.Parents, p.Type)
+	if p.Type == prenode.CountType {
+		s += fmt.Sprintf(", Threshold: %d", p.Threshold)
 	}
-
-	return numberPrenodes
+	return s + "}"
 }
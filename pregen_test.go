@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+func TestPrenodeLiteral(t *testing.T) {
+	for _, tc := range []struct {
+		p    *prenode.Prenode
+		want string
+	}{
+		{prenode.Or("a 1", "a 2"),
+			`&Prenode{Parents: []string{"a 1", "a 2"}, Type: 2}`},
+		{prenode.Count(2, "a", "b", "c"),
+			`&Prenode{Parents: []string{"a", "b", "c"}, Type: 7, Threshold: 2}`},
+	} {
+		if got := prenodeLiteral(tc.p); got != tc.want {
+			t.Errorf("want %s, got %s", tc.want, got)
+		}
+	}
+}
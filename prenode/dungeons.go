@@ -7,11 +7,15 @@ package prenode
 // ideally "enter <dungeon>" is the only overworld item the dungeon nodes
 // reference (and that node should not be defined here)
 //
-// make sure there's only *one* reference to each small key in a dungeon's
-// requirements. it might make key counting easier for the routing algorithm.
+// any small key opens any locked door in its dungeon, so each door is a count
+// of the dungeon's keys: one for the door itself, plus one for each of the
+// fewest other doors that have to be opened to get to it. the counts are
+// checked in TestKeyDoors. spending keys on the wrong doors can still leave a
+// player stuck, but that's up to the small key softlock check. doors are
+// labeled after the keys that are normally used on them.
 //
-// not that keys can NOT be numbered 1..n because of the code generation
-// syntax; label them A..N instead.
+// note that keys and doors can NOT be numbered 1..n because of the code
+// generation syntax; label them A..N instead.
 
 var d0Prenodes = map[string]*Prenode{
 	"d0 key chest":   And("enter d0"),
@@ -23,18 +27,22 @@ var d0Prenodes = map[string]*Prenode{
 
 var d1Prenodes = map[string]*Prenode{
 	"d1 key fall":       And("enter d1", "kill stalfos (throw)"),
-	"d1 map chest":      And("d1 key A", "kill stalfos"),
+	"d1 map chest":      And("d1 door A", "kill stalfos"),
 	"d1 compass chest":  And("d1 map chest"),
 	"d1 gasha chest":    And("d1 map chest", "kill goriya"),
 	"d1 bomb chest":     And("d1 map chest", "hit lever"),
 	"d1 key chest":      And("d1 map chest", "hit lever"),
-	"enter goriya bros": And("d1 bomb chest", "bombs", "d1 key B"),
+	"enter goriya bros": And("d1 bomb chest", "bombs", "d1 door B"),
 	"d1 satchel":        AndSlot("enter goriya bros", "kill goriya bros"),
 	"d1 boss key chest": And("d1 map chest", "ember seeds", "kill goriya (pit)"),
 	"d1 ring chest":     And("enter d1", "ember seeds"),
 	"enter aquamentus":  And("enter d1", "ember seeds", "d1 boss key"),
 	"d1 essence":        AndStep("enter aquamentus", "kill aquamentus"),
 
+	// doors
+	"d1 door A": Count(1, "d1 key A", "d1 key B"),
+	"d1 door B": Count(2, "d1 key A", "d1 key B"),
+
 	"d1 key A":    And("d1 key fall"),
 	"d1 key B":    And("d1 key chest"),
 	"d1 boss key": And("d1 boss key chest"),
@@ -59,7 +67,7 @@ var d2Prenodes = map[string]*Prenode{
 	"d2 key fall":          And("d2 torch room", "kill rope"),
 	"d2 arrow room 1":      And("d2 torch room", "ember seeds"),
 	"d2 arrow room 2":      And("enter d2 C", "bracelet"),
-	"d2 hardhat room":      And("d2 arrow room", "d2 key A"),
+	"d2 hardhat room":      And("d2 arrow room", "d2 door A"),
 	"d2 map chest":         And("d2 hardhat room", "remove pot"),
 	"d2 compass chest 1":   And("d2 torch room", "ember seeds", "kill rope"),
 	"d2 compass chest 2":   And("d2 arrow room", "kill goriya", "kill rope"),
@@ -72,11 +80,16 @@ var d2Prenodes = map[string]*Prenode{
 
 	// from here on it's entirely linear
 	"d2 10-rupee chest": And("d2 bomb wall", "bombs", "bracelet"),
-	"enter facade":      And("d2 10-rupee chest", "remove pot", "d2 key B"),
-	"d2 boss key chest": And("enter facade", "kill facade", "d2 key C", "bombs"),
+	"enter facade":      And("d2 10-rupee chest", "remove pot", "d2 door B"),
+	"d2 boss key chest": And("enter facade", "kill facade", "d2 door C", "bombs"),
 	"enter dodongo":     And("d2 boss key chest", "d2 boss key"),
 	"d2 essence":        AndStep("enter dodongo", "kill dodongo"),
 
+	// doors
+	"d2 door A": Count(1, "d2 key A", "d2 key B", "d2 key C"),
+	"d2 door B": Count(1, "d2 key A", "d2 key B", "d2 key C"),
+	"d2 door C": Count(2, "d2 key A", "d2 key B", "d2 key C"),
+
 	"d2 key A":    And("d2 key fall"),
 	"d2 key B":    And("d2 bomb key chest"),
 	"d2 key C":    And("d2 blade key chest"),
@@ -98,7 +111,7 @@ var d3Prenodes = map[string]*Prenode{
 	"d3 basement B out 1":    And("d3 basement B in", "jump"),
 	"d3 basement B out 2":    And("d3 trampoline stairs", "bracelet"),
 	"d3 rupee chest":         And("d3 feather stairs"),
-	"enter omuai":            And("d3 mimic stairs", "jump", "d3 key B"),
+	"enter omuai":            And("d3 mimic stairs", "jump", "d3 door B"),
 	"d3 gasha chest":         And("d3 mimic stairs", "jump"),
 	"d3 omuai stairs":        And("enter omuai", "kill omuai"),
 	"d3 boss key chest":      And("d3 omuai stairs", "jump"),
@@ -113,12 +126,16 @@ var d3Prenodes = map[string]*Prenode{
 	// second floor
 	"d3 bomb chest":           And("d3 mimic stairs"),
 	"d3 compass chest":        And("d3 bomb chest", "bombs"),
-	"d3 feather room":         And("d3 rupee chest", "d3 key A"),
+	"d3 feather room":         And("d3 rupee chest", "d3 door A"),
 	"d3 feather chest":        AndSlot("d3 feather room", "kill mimic"),
 	"d3 trampoline key chest": And("d3 trampoline stairs", "jump"),
 	"enter mothula":           And("d3 omuai stairs", "d3 boss key"),
 	"d3 essence":              AndStep("enter mothula", "kill mothula"),
 
+	// doors
+	"d3 door A": Count(1, "d3 key A", "d3 key B"),
+	"d3 door B": Count(1, "d3 key A", "d3 key B"),
+
 	// fixed items
 	"d3 key A":    And("d3 roller key chest"),
 	"d3 key B":    And("d3 trampoline key chest"),
@@ -135,25 +152,32 @@ var d4Prenodes = map[string]*Prenode{
 	"d4 dark key chest": And("d4 statue stairs", "jump"),
 
 	// 2F (ground floor), right branch
-	"d4 compass chest":   And("enter d4", "cross large pool", "d4 key A", "bombs"),
-	"d4 roller minecart": And("enter d4", "flippers", "d4 key A", "jump"),
+	"d4 compass chest":   And("enter d4", "cross large pool", "d4 door A", "bombs"),
+	"d4 roller minecart": And("enter d4", "flippers", "d4 door A", "jump"),
 	"d4 water key fall":  And("d4 roller minecart", "hit lever", "kill water tektite (throw)", "kill like-like (pit, throw)", "flippers"),
-	"d4 stalfos stairs":  And("d4 roller minecart", "kill shrouded stalfos (throw)", "jump", "d4 key B"),
+	"d4 stalfos stairs":  And("d4 roller minecart", "kill shrouded stalfos (throw)", "jump", "d4 door B"),
 
 	// 1F
 	"d4 pre-mid key":     And("d4 stalfos stairs"),
 	"enter agunima":      And("d4 pre-mid key", "jump"), // being nice
 	"d4 final minecart":  And("enter agunima", "kill agunima"),
 	"d4 torch key chest": And("enter agunima", "ember slingshot", "jump"),
-	"d4 slingshot chest": AndSlot("d4 final minecart", "d4 key C"),
-	"d4 boss key chest":  And("d4 final minecart", "hit very far lever", "jump", "d4 key D", "flippers"),
-	"d4 basement stairs": And("d4 final minecart", "hit far lever", "kill wizzrobe (pit, throw)", "d4 key E"),
+	"d4 slingshot chest": AndSlot("d4 final minecart", "d4 door C"),
+	"d4 boss key chest":  And("d4 final minecart", "hit very far lever", "jump", "d4 door D", "flippers"),
+	"d4 basement stairs": And("d4 final minecart", "hit far lever", "kill wizzrobe (pit, throw)", "d4 door E"),
 
 	// B1F
 	"d4 cross bridge": Or("ember slingshot", "long jump"),
 	"enter gohma":     And("d4 basement stairs", "d4 cross bridge", "d4 boss key"),
 	"d4 essence":      AndStep("enter gohma", "kill gohma"),
 
+	// doors
+	"d4 door A": Count(1, "d4 key A", "d4 key B", "d4 key C", "d4 key D", "d4 key E"),
+	"d4 door B": Count(2, "d4 key A", "d4 key B", "d4 key C", "d4 key D", "d4 key E"),
+	"d4 door C": Count(3, "d4 key A", "d4 key B", "d4 key C", "d4 key D", "d4 key E"),
+	"d4 door D": Count(3, "d4 key A", "d4 key B", "d4 key C", "d4 key D", "d4 key E"),
+	"d4 door E": Count(3, "d4 key A", "d4 key B", "d4 key C", "d4 key D", "d4 key E"),
+
 	// fixed items
 	"d4 key A":    And("d4 pot key fall"),
 	"d4 key B":    And("d4 dark key chest"),
@@ -178,7 +202,7 @@ var d5Prenodes = map[string]*Prenode{
 	"d5 stairs B out 2": And("d5 stairs C in", "bombs", "jump"),
 	// stairs B out is one-way
 	"d5 map chest":           And("d5 stairs B out"),
-	"d5 magnet gloves chest": AndSlot("d5 stairs B out", "cross large pool", "d5 key A"),
+	"d5 magnet gloves chest": AndSlot("d5 stairs B out", "cross large pool", "d5 door A"),
 	"d5 left key chest":      And("enter d5", "cross magnet gap"),
 	"d5 stairs C out":        And("d5 underground A", "bombs", "jump"),
 	"d5 stairs C in":         And("enter d5", "magnet gloves"),
@@ -189,13 +213,20 @@ var d5Prenodes = map[string]*Prenode{
 	"d5 float key chest":     And("d5 cart bay", "cross magnet gap"),
 	"d5 drop ball":           And("d5 cart bay", "hit lever", "kill darknut (pit)"),
 	"d5 pre-mid key chest":   And("d5 cart bay", "cross magnet gap"),
-	"enter syger":            And("d5 cart bay", "cross magnet gap", "d5 key B"),
+	"enter syger":            And("d5 cart bay", "cross magnet gap", "d5 door B"),
 	"d5 post-syger":          And("enter syger", "kill syger"),
-	"d5 push ball":           And("d5 drop ball", "d5 post-syger", "d5 key C", "magnet gloves"),
-	"d5 boss key spot":       And("d5 push ball", "d5 key D", "long jump", "sidescroll magnets"), // being nice
-	"enter digdogger":        And("d5 post-syger", "d5 key E", "jump", "magnet gloves", "d5 boss key"),
+	"d5 push ball":           And("d5 drop ball", "d5 post-syger", "d5 door C", "magnet gloves"),
+	"d5 boss key spot":       And("d5 push ball", "d5 door D", "long jump", "sidescroll magnets"), // being nice
+	"enter digdogger":        And("d5 post-syger", "d5 door E", "jump", "magnet gloves", "d5 boss key"),
 	"d5 essence":             AndStep("enter digdogger", "kill digdogger"),
 
+	// doors
+	"d5 door A": Count(1, "d5 key A", "d5 key B", "d5 key C", "d5 key D", "d5 key E"),
+	"d5 door B": Count(1, "d5 key A", "d5 key B", "d5 key C", "d5 key D", "d5 key E"),
+	"d5 door C": Count(2, "d5 key A", "d5 key B", "d5 key C", "d5 key D", "d5 key E"),
+	"d5 door D": Count(3, "d5 key A", "d5 key B", "d5 key C", "d5 key D", "d5 key E"),
+	"d5 door E": Count(4, "d5 key A", "d5 key B", "d5 key C", "d5 key D", "d5 key E"),
+
 	// fixed items
 	"d5 key A":    And("d5 cart key chest"),
 	"d5 key B":    And("d5 left key chest"),
@@ -212,8 +243,8 @@ var d6Prenodes = map[string]*Prenode{
 	"d6 magkey ball":     And("d6 spinner", "magnet gloves", "jump"),
 	"d6 magkey jump":     And("pegasus jump L-2"),
 	"d6 magnet key fall": Or("d6 magkey ball", "d6 magkey jump"),
-	"d6 compass chest":   And("d6 spinner", "d6 key A"),
-	"d6 crumble stairs":  And("d6 spinner", "d6 key A", "long jump"),
+	"d6 compass chest":   And("d6 spinner", "d6 door A"),
+	"d6 crumble stairs":  And("d6 spinner", "d6 door A", "long jump"),
 	"d6 key skip":        And("d6 armos room", "jump", "break crystal"),
 	"d6 map chest 1":     And("d6 key skip"),
 	"d6 map chest 2":     And("d6 spinner"),
@@ -233,7 +264,7 @@ var d6Prenodes = map[string]*Prenode{
 
 	// 3F
 	"d6 vire key chest": And("d6 gauntlet stairs", "kill stalfos", "jump"),
-	"enter vire":        And("d6 gauntlet stairs", "kill stalfos", "d6 key B"),
+	"enter vire":        And("d6 gauntlet stairs", "kill stalfos", "d6 door B"),
 	"d6 rng stairs":     And("enter vire", "kill vire"),
 
 	// 4F
@@ -244,6 +275,10 @@ var d6Prenodes = map[string]*Prenode{
 	"enter manhandla":  And("d6 pre-boss room", "jump", "hit far switch", "d6 boss key"),
 	"d6 essence":       AndStep("enter manhandla", "kill manhandla"),
 
+	// doors
+	"d6 door A": Count(1, "d6 key A", "d6 key B", "d6 key C"),
+	"d6 door B": Count(1, "d6 key A", "d6 key B", "d6 key C"),
+
 	// fixed items
 	"d6 key A":    And("d6 magnet key fall"),
 	"d6 key B":    And("d6 vire key chest"),
@@ -254,10 +289,10 @@ var d6Prenodes = map[string]*Prenode{
 var d7Prenodes = map[string]*Prenode{
 	// 1F
 	"d7 wizzrobe key chest": And("enter d7", "kill wizzrobe"),
-	"d7 ring chest":         And("enter d7", "d7 key E"),
+	"d7 ring chest":         And("enter d7", "d7 door E"),
 	"enter poe A":           And("d7 ring chest", "ember slingshot"),
 	"d7 compass chest":      And("enter d7", "bombs"),
-	"d7 map chest":          And("d7 pot room", "d7 key A"), // not sure but doesn't matter
+	"d7 map chest":          And("d7 pot room", "d7 door A"), // not sure but doesn't matter
 
 	// B1F
 	"d7 armos room 1":       And("enter d7", "enter poe A", "kill poe sister", "bracelet"),
@@ -265,7 +300,7 @@ var d7Prenodes = map[string]*Prenode{
 	"d7 zol key fall":       And("d7 armos room", "jump"),
 	"d7 pot room":           And("d7 armos room", "kill armos"), // being nice
 	"d7 magunesu key chest": And("d7 magunesu room", "kill magunesu", "jump", "magnet gloves"),
-	"enter poe B":           And("d7 pot room", "d7 key A", "d7 key B"),
+	"enter poe B":           And("d7 pot room", "d7 door A", "d7 door B"),
 	"d7 water stairs":       And("enter poe B", "pegasus satchel", "ember seeds", "kill poe sister", "flippers"),
 	"d7 cape chest":         AndSlot("d7 trampoline pair", "jump", "kill stalfos (pit)"),
 
@@ -278,16 +313,23 @@ var d7Prenodes = map[string]*Prenode{
 	"d7 cross bridge 2":   And("feather L-2"),
 	"d7 cross bridge 3":   And("jump", "magnet gloves"),
 	"d7 trampoline pair":  And("d7 water stairs", "d7 cross bridge"),
-	"d7 moldorm room":     And("d7 water stairs", "d7 key C", "feather L-2"),
+	"d7 moldorm room":     And("d7 water stairs", "d7 door C", "feather L-2"),
 	"enter poe sisters 1": And("d7 moldorm room", "kill moldorm", "remove pot", "feather L-2"),
 	"enter poe sisters 2": And("d7 moldorm room", "kill moldorm", "pegasus jump L-2"),
 	"d7 stairs room":      And("enter poe sisters", "kill poe sister"),
 	"d7 enter skipped":    And("d7 stairs room", "magnet gloves", "jump"),
 	"d7 skipped key poof": And("d7 enter skipped", "kill wizzrobe (pit)", "kill stalfos (pit)"),
-	"d7 boss key chest":   And("d7 stairs room", "d7 key D", "pegasus jump L-2", "hit switch", "kill stalfos"),
+	"d7 boss key chest":   And("d7 stairs room", "d7 door D", "pegasus jump L-2", "hit switch", "kill stalfos"),
 	"enter gleeok":        And("d7 stairs room", "d7 boss key"),
 	"d7 essence":          AndStep("enter gleeok", "kill gleeok"),
 
+	// doors
+	"d7 door A": Count(1, "d7 key A", "d7 key B", "d7 key C", "d7 key D", "d7 key E"),
+	"d7 door B": Count(2, "d7 key A", "d7 key B", "d7 key C", "d7 key D", "d7 key E"),
+	"d7 door C": Count(3, "d7 key A", "d7 key B", "d7 key C", "d7 key D", "d7 key E"),
+	"d7 door D": Count(4, "d7 key A", "d7 key B", "d7 key C", "d7 key D", "d7 key E"),
+	"d7 door E": Count(1, "d7 key A", "d7 key B", "d7 key C", "d7 key D", "d7 key E"),
+
 	// fixed items
 	"d7 key A":    And("d7 wizzrobe key chest"),
 	"d7 key B":    And("d7 zol key fall"),
@@ -299,7 +341,8 @@ var d7Prenodes = map[string]*Prenode{
 
 // ignoring everything unnecessary in a route that does obtain HSS.
 // keys get wonky but i'm just using the ones you'd get in an HSS skip route,
-// except for the locked doors that aren't in that route.
+// except for the locked doors that aren't in that route. a few doors are
+// treated as one, since they're opened by the same key in that route.
 var d8Prenodes = map[string]*Prenode{
 	// 1F
 	"d8 eye key fall":      And("enter d8", "slingshot", "remove pot"),
@@ -314,30 +357,37 @@ var d8Prenodes = map[string]*Prenode{
 	"d8 cross bridge B":    Or("boomerang L-2", "pegasus jump L-2"),
 	// technically there are pots to throw in this room but i don't care
 	"d8 boss key chest": And("d8 cross bridge B", "kill keese", "kill pols voice (pit)"),
-	"d8 crystal room":   And("d8 ice puzzle room", "d8 key A"),
+	"d8 crystal room":   And("d8 ice puzzle room", "d8 door A"),
 	"d8 armos key fall": And("d8 crystal room", "bombs"),
-	"d8 NW crystal":     And("d8 crystal room", "d8 key B"),
+	"d8 NW crystal":     And("d8 crystal room", "d8 door B"),
 	"d8 NE crystal":     And("d8 crystal room", "hit lever"),
 	"d8 SE crystal":     And("d8 crystal room"),
-	"d8 SW crystal":     And("d8 crystal room", "d8 key E"),
+	"d8 SW crystal":     And("d8 crystal room", "d8 door E"),
 	"d8 pot key chest":  And("d8 SE crystal", "d8 NE crystal", "remove pot"),
 
 	// B1F
 	"d8 cross pot path":    Or("remove pot", "jump"),
-	"d8 double rollers":    And("d8 hardhat room", "d8 key A", "d8 cross pot path"),
+	"d8 double rollers":    And("d8 hardhat room", "d8 door A", "d8 cross pot path"),
 	"d8 blade room":        And("d8 double rollers", "long jump"),
-	"d8 spinner":           And("d8 blade room", "d8 key B"),
+	"d8 spinner":           And("d8 blade room", "d8 door B"),
 	"d8 place ball":        And("d8 spinner", "magnet gloves"),
 	"d8 HSS chest":         AndSlot("d8 spinner", "magnet gloves"),
 	"d8 HSS stairs 1":      And("d8 HSS chest", "pegasus jump L-2"),
 	"d8 HSS stairs 2":      And("d8 HSS chest", "d8 place ball"),
 	"d8 spinner key chest": And("d8 HSS stairs"),
-	"enter frypolar":       And("d8 HSS stairs", "d8 key C"),
+	"enter frypolar":       And("d8 HSS stairs", "d8 door C"),
 	"d8 frypolar stairs":   And("enter frypolar", "kill frypolar", "ember seeds", "slingshot L-2"),
 	"d8 lava key chest":    And("d8 SE crystal"),
-	"enter medusa head":    And("d8 SW crystal", "d8 SE crystal", "d8 NW crystal", "d8 key F", "d8 boss key"),
+	"enter medusa head":    And("d8 SW crystal", "d8 SE crystal", "d8 NW crystal", "d8 door F", "d8 boss key"),
 	"d8 essence":           AndStep("enter medusa head", "kill medusa head"),
 
+	// doors
+	"d8 door A": Count(1, "d8 key A", "d8 key B", "d8 key C", "d8 key D", "d8 key E", "d8 key F", "d8 key G"),
+	"d8 door B": Count(2, "d8 key A", "d8 key B", "d8 key C", "d8 key D", "d8 key E", "d8 key F", "d8 key G"),
+	"d8 door C": Count(3, "d8 key A", "d8 key B", "d8 key C", "d8 key D", "d8 key E", "d8 key F", "d8 key G"),
+	"d8 door E": Count(2, "d8 key A", "d8 key B", "d8 key C", "d8 key D", "d8 key E", "d8 key F", "d8 key G"),
+	"d8 door F": Count(4, "d8 key A", "d8 key B", "d8 key C", "d8 key D", "d8 key E", "d8 key F", "d8 key G"),
+
 	// fixed items
 	"d8 key A":    And("d8 eye key fall"),
 	"d8 key B":    And("d8 hardhat key fall"),
@@ -0,0 +1,131 @@
+package prenode
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var (
+	keyRegexp  = regexp.MustCompile(`^d\d (small key|key [A-Z])$`)
+	doorRegexp = regexp.MustCompile(`^d\d door [A-Z]$`)
+)
+
+// make sure each door counts all of its dungeon's keys, and needs as many as
+// it takes to get through it
+func TestKeyDoors(t *testing.T) {
+	all := GetAll()
+	keys := make(map[string][]string)
+	doors := make([]string, 0)
+	for name := range all {
+		if keyRegexp.MatchString(name) {
+			keys[name[:2]] = append(keys[name[:2]], name)
+		} else if doorRegexp.MatchString(name) {
+			doors = append(doors, name)
+		}
+	}
+	sort.Strings(doors)
+
+	for _, door := range doors {
+		pn := all[door]
+		parents := append([]string{}, pn.Parents...)
+		sort.Strings(parents)
+		sort.Strings(keys[door[:2]])
+		if pn.Type != CountType || len(parents) != len(keys[door[:2]]) {
+			t.Errorf("%s doesn't count all the keys in its dungeon", door)
+			continue
+		}
+		for i, key := range keys[door[:2]] {
+			if parents[i] != key {
+				t.Errorf("%s doesn't count all the keys in its dungeon", door)
+				break
+			}
+		}
+
+		// the door takes one key, plus the fewest other doors that can be
+		// opened to get to it
+		others := make([]string, 0)
+		for _, other := range doors {
+			if other != door && other[:2] == door[:2] {
+				others = append(others, other)
+			}
+		}
+		want := -1
+		for set := uint(0); set < 1<<uint(len(others)); set++ {
+			open := make([]string, 0)
+			for i, other := range others {
+				if set&(1<<uint(i)) != 0 {
+					open = append(open, other)
+				}
+			}
+			if (want < 0 || len(open)+1 < want) && isAtHand(all, door, open) {
+				want = len(open) + 1
+			}
+		}
+		if pn.Threshold != want {
+			t.Errorf("%s: want count %d, got %d", door, want, pn.Threshold)
+		}
+	}
+}
+
+// returns true iff something behind the door would be reached if every item
+// and key were, along with the door and the given other doors.
+func isAtHand(all map[string]*Prenode, door string, open []string) bool {
+	reached := reach(all, append([]string{door}, open...))
+	for name, pn := range all {
+		if reached[name] && hasParent(pn, door) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the names of the prenodes that are reached when every item and key
+// is, along with the given doors.
+func reach(all map[string]*Prenode, doors []string) map[string]bool {
+	reached := make(map[string]bool)
+	for name, pn := range all {
+		if pn.Type == RootType || keyRegexp.MatchString(name) {
+			reached[name] = true
+		}
+	}
+	for _, door := range doors {
+		reached[door] = true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for name, pn := range all {
+			if reached[name] || doorRegexp.MatchString(name) {
+				continue
+			}
+			n := 0
+			for _, parent := range pn.Parents {
+				if reached[parent] {
+					n++
+				}
+			}
+			switch pn.Type {
+			case AndType, AndSlotType, AndStepType:
+				reached[name] = n == len(pn.Parents)
+			case OrType, OrSlotType, OrStepType:
+				reached[name] = n > 0
+			case CountType:
+				reached[name] = n >= pn.Threshold
+			}
+			changed = changed || reached[name]
+		}
+	}
+
+	return reached
+}
+
+// returns true iff the named parent is one of the prenode's parents
+func hasParent(pn *Prenode, parent string) bool {
+	for _, name := range pn.Parents {
+		if name == parent {
+			return true
+		}
+	}
+	return false
+}
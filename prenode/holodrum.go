@@ -3,7 +3,6 @@ package prenode
 // overworld route logic
 
 // portal parents are defined here since they're mostly overworld nodes
-// see subrosia.go for the note about "remove stuck bush"
 
 var portalPrenodes = map[string]*Prenode{
 	"rosa portal in":         And("sokra stump", "remove bush"),
@@ -35,16 +34,13 @@ var portalPrenodes = map[string]*Prenode{
 
 	// effectively one-way
 	"remains portal 1": And("temple remains", "shovel", "remove bush", "pegasus jump L-2"),
-	"remains portal 2": And("temple remains", "spring", "remove flower", "remove bush", "pegasus jump L-2", "winter"),
-	"remains portal 3": And("temple remains", "summer", "remove bush", "pegasus jump L-2", "winter"),
-	"remains portal 4": And("temple remains", "autumn", "remove bush", "jump", "winter"),
+	"remains portal 2": And("temple remains", "temple remains spring", "remove flower", "remove bush", "pegasus jump L-2", "temple remains winter"),
+	"remains portal 3": And("temple remains", "temple remains summer", "remove bush", "pegasus jump L-2", "temple remains winter"),
+	"remains portal 4": And("temple remains", "temple remains autumn", "remove bush", "jump", "temple remains winter"),
 
 	// dead end
-	"d8 portal 1": And("remains portal", "bombs", "summer", "long jump", "magnet gloves"),
-	"d8 portal 2": And("remains portal", "bombs", "summer", "pegasus jump L-2"),
-
-	// this is strictly for softlock checking; see safety.go
-	"remove stuck bush": Or("sword", "boomerang L-2", "bracelet"),
+	"d8 portal 1": And("remains portal", "bombs", "temple remains summer", "long jump", "magnet gloves"),
+	"d8 portal 2": And("remains portal", "bombs", "temple remains summer", "pegasus jump L-2"),
 }
 
 var holodrumPrenodes = map[string]*Prenode{
@@ -63,14 +59,14 @@ var holodrumPrenodes = map[string]*Prenode{
 	"ember tree":      AndStep("horon village"),
 	"sokra stump 1":   And("horon village", "ember seeds"),
 	"sokra stump 2":   And("rosa portal", "remove bush"),
-	"sokra stump 3":   And("post-d2 stump", "winter"),
+	"sokra stump 3":   And("post-d2 stump", "eastern suburbs winter"),
 	"sokra stump 4":   And("post-d2 stump", "cross water gap"),
-	"post-d2 stump 1": And("sokra stump", "winter"),
+	"post-d2 stump 1": And("sokra stump", "eastern suburbs winter"),
 	"post-d2 stump 2": And("sokra stump", "cross water gap"),
 	"post-d2 stump 3": And("sunken city"),
 	"post-d2 stump 4": And("mystery tree"),
-	"shovel gift":     AndSlot("post-d2 stump", "winter"),
-	"mystery tree 1":  And("post-d2 stump", "winter", "shovel"),
+	"shovel gift":     AndSlot("post-d2 stump", "woods of winter winter"),
+	"mystery tree 1":  And("post-d2 stump", "woods of winter winter", "shovel"),
 	"mystery tree 2":  And("post-d2 stump", "jump"),
 	"mystery tree 3":  And("sokra stump", "cross water gap"),
 	"mystery tree 4":  And("sunken city"),
@@ -98,16 +94,16 @@ var holodrumPrenodes = map[string]*Prenode{
 	"pegasus tree":         OrStep("pegasus tree A", "pegasus tree B", "pegasus tree C"),
 	"pegasus tree 1":       And("ghastly stump", "ricky"),
 	"pegasus tree 2":       And("ghastly stump", "feather L-2"),
-	"pegasus tree 3":       And("ghastly stump", "summer"),
+	"pegasus tree 3":       And("ghastly stump", "holodrum plain summer"),
 	"floodgate key gift":   AndSlot("pegasus tree", "hit lever"),
 	"spool swamp 1":        And("open floodgate"),
 	"spool swamp 2":        And("ghastly stump", "remove bush", "flippers"),
 	"spool swamp 3":        And("scent tree", "flippers"),
 	"square jewel chest":   AndSlot("square jewel chest A", "square jewel chest B", "square jewel chest C"),
-	"square jewel chest A": And("open floodgate", "winter", "animal flute"),
-	"square jewel chest B": And("open floodgate", "winter", "long jump", "bombs"),
-	"square jewel chest C": And("open floodgate", "winter", "flippers", "bombs"),
-	"enter d3":             AndStep("open floodgate", "summer"),
+	"square jewel chest A": And("open floodgate", "spool swamp winter", "animal flute"),
+	"square jewel chest B": And("open floodgate", "spool swamp winter", "long jump", "bombs"),
+	"square jewel chest C": And("open floodgate", "spool swamp winter", "flippers", "bombs"),
+	"enter d3":             AndStep("open floodgate", "spool swamp summer"),
 
 	// d3->d4
 	"natzu 1":               And("scent tree", "jump", "animal flute"),
@@ -115,7 +111,7 @@ var holodrumPrenodes = map[string]*Prenode{
 	"natzu 3":               And("sunken city", "animal flute"),
 	"sunken city 1":         And("natzu", "animal flute"),
 	"sunken city 2":         And("mount cucco", "flippers"),
-	"sunken city 3":         And("post-d2 stump", "spring"),
+	"sunken city 3":         And("post-d2 stump", "woods of winter spring"),
 	"sunken gale tree":      AndStep("sunken city", "cross water gap"),
 	"dimitri":               And("sunken gale tree", "bombs"),
 	"master's plaque chest": AndSlot("sunken gale tree", "dimitri", "sword", "cross water gap"),
@@ -125,14 +121,14 @@ var holodrumPrenodes = map[string]*Prenode{
 	"mount cucco 3":         And("mountain portal"),
 	"banana harvest item":   Or("sword", "fool's ore"),
 	"spring banana cucco":   And("mount cucco", "bracelet"),
-	"spring banana tree":    AndSlot("spring banana cucco", "spring", "jump", "banana harvest item"),
+	"spring banana tree":    AndSlot("spring banana cucco", "sunken city spring", "jump", "banana harvest item"),
 	"moosh":                 And("mount cucco", "spring banana"),
 	"dragon key cross 1":    And("mount cucco", "moosh"),
 	"dragon key cross 2":    And("mount cucco", "pegasus jump L-2"),
 	"dragon key spot":       AndSlot("dragon key cross"), // wraps generated node
-	"mario cave":            And("mount cucco", "spring"),
-	"dragon keyhole":        And("mario cave", "winter", "jump", "bracelet"),
-	"enter d4":              AndStep("dragon key", "dragon keyhole", "summer", "cross water gap"),
+	"mario cave":            And("mount cucco", "sunken city spring"),
+	"dragon keyhole":        And("mario cave", "sunken city winter", "jump", "bracelet"),
+	"enter d4":              AndStep("dragon key", "dragon keyhole", "sunken city summer", "cross water gap"),
 	"pyramid jewel spot":    AndSlot("mario cave", "flippers"),
 
 	// goron mountain
@@ -143,15 +139,15 @@ var holodrumPrenodes = map[string]*Prenode{
 
 	// d4->d5
 	"eyeglass lake": And("north horon stump", "jump"),
-	"enter d5":      AndStep("eyeglass lake", "autumn", "remove mushroom"),
+	"enter d5":      AndStep("eyeglass lake", "north horon autumn", "remove mushroom"),
 
 	// d5->d6; i'm treating tarm ruins like it's one way (like it normally is)
 	"x-shaped jewel chest": AndSlot("horon village", "mystery slingshot", "kill moldorm"),
 	"round jewel gift":     AndSlot("spool swamp", "flippers"),
 	"tarm ruins":           And("pegasus tree", "square jewel", "pyramid jewel", "round jewel", "x-shaped jewel"),
-	"lost woods":           And("tarm ruins", "summer", "winter", "autumn", "bracelet"),
-	"tarm gale tree":       AndStep("lost woods", "winter", "autumn", "spring", "summer"),
-	"enter d6":             AndStep("tarm gale tree", "winter", "shovel", "spring", "remove flower"),
+	"lost woods":           And("tarm ruins", "lost woods summer", "lost woods winter", "lost woods autumn", "bracelet"),
+	"tarm gale tree":       AndStep("lost woods", "lost woods winter", "lost woods autumn", "lost woods spring", "lost woods summer"),
+	"enter d6":             AndStep("tarm gale tree", "tarm ruins winter", "shovel", "tarm ruins spring", "remove flower"),
 
 	// d6->d7
 	"eastern coast":   And("horon village", "ember seeds"),
@@ -159,7 +155,7 @@ var holodrumPrenodes = map[string]*Prenode{
 	"rusty bell spot": AndSlot("samasa desert", "bracelet"),
 	"pirate ship":     And("pirate's bell"),
 	"graveyard 1":     And("pirate ship", "long jump"),
-	"graveyard 2":     And("pirate ship", "bombs", "jump", "summer"),
+	"graveyard 2":     And("pirate ship", "bombs", "jump", "western coast summer"),
 	"enter d7":        AndStep("graveyard", "shovel"),
 
 	// d7->d8
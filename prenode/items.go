@@ -97,7 +97,7 @@ var itemPrenodes = map[string]*Prenode{
 	"cross water gap":  Or("flippers", "jump"),
 	"cross large pool": Or("flippers", "pegasus jump L-2"),
 
-	"sword L-2": And("lost woods", "winter", "autumn", "spring", "summer"),
+	"sword L-2": And("lost woods", "lost woods winter", "lost woods autumn", "lost woods spring", "lost woods summer"),
 
 	"ribbon":      And("star ore", "beach"),
 	"bomb flower": And("furnace", "jump", "bracelet"),
@@ -0,0 +1,211 @@
+package prenode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// this file reads and writes the logic in a plain text format, so that it can
+// be changed without recompiling. the format looks like this:
+//
+//     # comments start with a pound sign
+//     [holodrum]
+//     horon village 1: and
+//         north horon stump
+//         remove bush
+//     maku seed: count 8
+//         d1 essence
+//         ...
+//
+// a name in brackets starts a group (see GetGroups). each prenode is a line
+// with its name and type, followed by one indented line per parent. the types
+// are root, and, or, andslot, orslot, andstep, orstep, and count N. names
+// ending in numbers are combined into Or prenodes at load time, the same way
+// the generated prenodes are made.
+
+var typeNames = map[Type]string{
+	RootType:    "root",
+	AndType:     "and",
+	OrType:      "or",
+	AndSlotType: "andslot",
+	OrSlotType:  "orslot",
+	AndStepType: "andstep",
+	OrStepType:  "orstep",
+	CountType:   "count",
+}
+
+// NumberPrenodes returns Or prenodes for the keys in the given maps that end
+// in numbers. for example, "scent tree 1" and "scent tree 2" make "scent tree"
+// an Or prenode with both as parents.
+func NumberPrenodes(maps ...map[string]*Prenode) map[string]*Prenode {
+	numberPrenodes := make(map[string]*Prenode)
+	numberRegexp := regexp.MustCompile(`(^.+) \d+$`)
+
+	for _, prenodes := range maps {
+		for key := range prenodes {
+			matches := numberRegexp.FindAllStringSubmatch(key, 1)
+			if matches != nil {
+				realKey := matches[0][1]
+				if pt, ok := numberPrenodes[realKey]; ok {
+					// sort for consistent order and minimal diffs
+					parents := sort.StringSlice(append(pt.Parents, key))
+					parents.Sort()
+					numberPrenodes[realKey] = Or(parents...)
+				} else {
+					numberPrenodes[realKey] = Or(key)
+				}
+			}
+		}
+	}
+
+	return numberPrenodes
+}
+
+// ExportLogic writes the explicitly declared prenodes in the text format,
+// sorted by group and name.
+func ExportLogic(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# oracle of seasons randomizer logic")
+
+	groupNames := make([]string, 0, len(prenodeGroups))
+	for name := range prenodeGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	for _, groupName := range groupNames {
+		fmt.Fprintf(bw, "\n[%s]\n", groupName)
+		group := prenodeGroups[groupName]
+		for _, name := range sortedKeys(group) {
+			p := group[name]
+			if p.Type == CountType {
+				fmt.Fprintf(bw, "%s: count %d\n", name, p.Threshold)
+			} else {
+				fmt.Fprintf(bw, "%s: %s\n", name, typeNames[p.Type])
+			}
+			for _, parent := range p.Parents {
+				fmt.Fprintf(bw, "\t%s\n", parent)
+			}
+		}
+	}
+
+	return bw.Flush()
+}
+
+// LoadLogic reads prenodes in the text format and replaces all the built-in
+// ones with them, including the generated ones. If check isn't nil, it's given
+// all the loaded prenodes first, so that the caller can reject logic that's
+// missing things it depends on. Nothing is replaced if there's an error.
+func LoadLogic(r io.Reader, check func(map[string]*Prenode) error) error {
+	groups := make(map[string]map[string]*Prenode)
+	all := make(map[string]*Prenode)
+	var group map[string]*Prenode
+	var current *Prenode
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			name := trimmed[1 : len(trimmed)-1]
+			if groups[name] == nil {
+				groups[name] = make(map[string]*Prenode)
+			}
+			group, current = groups[name], nil
+		case line[0] == ' ' || line[0] == '\t':
+			if current == nil {
+				return fmt.Errorf("line %d: parent without a prenode", lineNum)
+			}
+			current.Parents = append(current.Parents, trimmed)
+		default:
+			if group == nil {
+				return fmt.Errorf("line %d: prenode outside of a group", lineNum)
+			}
+			name, p, err := parsePrenodeLine(trimmed)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", lineNum, err)
+			}
+			if all[name] != nil {
+				return fmt.Errorf("line %d: duplicate prenode: %s", lineNum, name)
+			}
+			group[name], all[name], current = p, p, p
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// make sure everything referred to exists. generated prenodes replace
+	// declared ones with the same name, like they do in GetAll.
+	generated := NumberPrenodes(all)
+	appendPrenodes(all, generated)
+	for _, name := range sortedKeys(all) {
+		for _, parent := range all[name].Parents {
+			if all[parent] == nil {
+				return fmt.Errorf("%s: no such parent: %s", name, parent)
+			}
+		}
+	}
+	if groups["base items"] == nil {
+		return fmt.Errorf("no [base items] group")
+	}
+	if check != nil {
+		if err := check(all); err != nil {
+			return err
+		}
+	}
+
+	prenodeGroups, generatedPrenodes = groups, generated
+	return nil
+}
+
+// parses a "name: type" line
+func parsePrenodeLine(line string) (string, *Prenode, error) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", nil, fmt.Errorf("missing colon")
+	}
+	name := strings.TrimSpace(line[:i])
+	fields := strings.Fields(line[i+1:])
+	if name == "" || len(fields) == 0 {
+		return "", nil, fmt.Errorf("missing name or type")
+	}
+
+	if fields[0] == typeNames[CountType] {
+		if len(fields) != 2 {
+			return "", nil, fmt.Errorf("count needs a number")
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return "", nil, err
+		}
+		return name, Count(n), nil
+	}
+
+	for t, typeName := range typeNames {
+		if fields[0] == typeName && len(fields) == 1 {
+			return name, &Prenode{Type: t}, nil
+		}
+	}
+	return "", nil, fmt.Errorf("unknown type: %s", strings.Join(fields, " "))
+}
+
+// returns the keys of the map in alphabetical order
+func sortedKeys(m map[string]*Prenode) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,82 @@
+package prenode
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogicRoundTrip(t *testing.T) {
+	oldGroups, oldGenerated := prenodeGroups, generatedPrenodes
+	defer func() {
+		prenodeGroups, generatedPrenodes = oldGroups, oldGenerated
+	}()
+
+	want := GetAll()
+	b := new(bytes.Buffer)
+	if err := ExportLogic(b); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadLogic(b, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got := GetAll()
+	if len(got) != len(want) {
+		t.Errorf("want %d prenodes, got %d", len(want), len(got))
+	}
+	for name, p := range want {
+		if len(p.Parents) == 0 {
+			p = &Prenode{Parents: nil, Type: p.Type, Threshold: p.Threshold}
+		}
+		if !reflect.DeepEqual(got[name], p) {
+			t.Errorf("%s: want %#v, got %#v", name, p, got[name])
+		}
+	}
+}
+
+func TestLoadLogicErrors(t *testing.T) {
+	oldGroups, oldGenerated := prenodeGroups, generatedPrenodes
+	defer func() {
+		prenodeGroups, generatedPrenodes = oldGroups, oldGenerated
+	}()
+
+	for _, s := range []string{
+		"a: and\n",                         // no group
+		"[base items]\n\tb\n",              // no prenode
+		"[base items]\na: nand\n",          // bad type
+		"[base items]\na: count x\n",       // bad count
+		"[base items]\na: root\na: root\n", // duplicate
+		"[base items]\na: and\n\tb\n",      // no such parent
+		"[items]\na: root\n",               // no base items
+	} {
+		if err := LoadLogic(strings.NewReader(s), nil); err == nil {
+			t.Errorf("no error for %q", s)
+		}
+	}
+	// or if the caller's check fails
+	err := LoadLogic(strings.NewReader("[base items]\nx: root\n"),
+		func(prenodes map[string]*Prenode) error {
+			if prenodes["d1 essence"] == nil {
+				return fmt.Errorf("no d1 essence")
+			}
+			return nil
+		})
+	if err == nil {
+		t.Error("no error from check")
+	}
+	if GetAll()["d1 essence"] == nil {
+		t.Fatal("logic replaced despite errors")
+	}
+
+	// numbered prenodes get combined
+	s := "[base items]\nx: root\n[test]\na 1: and\n\tx\na 2: root\nb: or\n\ta\n"
+	if err := LoadLogic(strings.NewReader(s), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetAll()["a"]; got == nil || len(got.Parents) != 2 {
+		t.Errorf("bad numbered prenode: %#v", got)
+	}
+}
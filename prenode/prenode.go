@@ -9,6 +9,8 @@ package prenode
 //     need both energy ring and fist ring, but if you did, then you'd need to
 //     have the L-2 ring box to do so without danger of soft locking.
 
+import "sort"
+
 // A Type identifies whether a prenode is and And, Or, or Root node, whether it
 // is an item slot, and whether it is a non-item slot milestone.
 type Type int
@@ -16,7 +18,8 @@ type Type int
 // And, Or, and Root are pretty self-explanatory. One with a Slot suffix is an
 // item slot, and one with a Step suffix is treated as a milestone for routing
 // purposes. Slot types are also treated as steps; see the Point.IsStep()
-// function.
+// function. Count prenodes are satisfied by some number of their parents; see
+// the Count function.
 //
 // The following function are half syntactic sugar for declaring large lists of
 // node relationships.
@@ -28,13 +31,15 @@ const (
 	OrSlotType
 	AndStepType
 	OrStepType
+	CountType
 )
 
 // A Prenode is a mapping of strings that will become And or Or nodes in the
 // graph.
 type Prenode struct {
-	Parents []string
-	Type    Type
+	Parents   []string
+	Type      Type
+	Threshold int // only used by Count prenodes
 }
 
 // CreateFunc returns a function that creates a graph node from a list of
@@ -57,20 +62,65 @@ var (
 	OrStep  = CreateFunc(OrStepType)
 )
 
+// Count returns a prenode that is satisfied when at least n of its parents
+// are.
+func Count(n int, a ...string) *Prenode {
+	return &Prenode{Parents: a, Type: CountType, Threshold: n}
+}
+
 // BaseItems returns a map of item prenodes that may be assigned to slots.
 func BaseItems() map[string]*Prenode {
-	return baseItemPrenodes
+	return prenodeGroups["base items"]
+}
+
+// the declared prenode maps, by name. the names are the same as the files
+// they're in, except where a file has more than one map.
+var prenodeGroups = map[string]map[string]*Prenode{
+	"items":         itemPrenodes,
+	"base items":    baseItemPrenodes,
+	"ignored items": ignoredBaseItemPrenodes,
+	"kill":          killPrenodes,
+	"holodrum":      holodrumPrenodes,
+	"portals":       portalPrenodes,
+	"seasons":       seasonPrenodes,
+	"subrosia":      subrosiaPrenodes,
+	"d0":            d0Prenodes,
+	"d1":            d1Prenodes,
+	"d2":            d2Prenodes,
+	"d3":            d3Prenodes,
+	"d4":            d4Prenodes,
+	"d5":            d5Prenodes,
+	"d6":            d6Prenodes,
+	"d7":            d7Prenodes,
+	"d8":            d8Prenodes,
+	"d9":            d9Prenodes,
+}
+
+// GetGroups returns the explicitly declared prenodes, grouped by the map
+// they're declared in, plus the generated prenodes as "generated".
+func GetGroups() map[string]map[string]*Prenode {
+	groups := make(map[string]map[string]*Prenode, len(prenodeGroups)+1)
+	for name, group := range prenodeGroups {
+		groups[name] = group
+	}
+	groups["generated"] = generatedPrenodes
+	return groups
 }
 
 // GetNonGenerated returns a map of all prenodes that are explicitly declared,
-// and not automatically generated.
+// and not automatically generated. If a name is declared in more than one
+// group, the group that comes last alphabetically wins.
 func GetNonGenerated() map[string]*Prenode {
+	groupNames := make([]string, 0, len(prenodeGroups))
+	for name := range prenodeGroups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
 	nonGenerated := make(map[string]*Prenode)
-	appendPrenodes(nonGenerated,
-		itemPrenodes, baseItemPrenodes, ignoredBaseItemPrenodes, killPrenodes,
-		holodrumPrenodes, subrosiaPrenodes, portalPrenodes,
-		d0Prenodes, d1Prenodes, d2Prenodes, d3Prenodes, d4Prenodes,
-		d5Prenodes, d6Prenodes, d7Prenodes, d8Prenodes, d9Prenodes)
+	for _, name := range groupNames {
+		appendPrenodes(nonGenerated, prenodeGroups[name])
+	}
 	return nonGenerated
 }
 
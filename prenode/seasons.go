@@ -0,0 +1,29 @@
+package prenode
+
+// seasons in each overworld area. by default these just require the season to
+// be set with the rod, but if the default seasons are randomized, the area's
+// default season is available without it. see seasons.go in the main package.
+
+var seasonPrenodes = map[string]*Prenode{
+	"north horon autumn":     Or("autumn"),
+	"eastern suburbs winter": Or("winter"),
+	"woods of winter spring": Or("spring"),
+	"woods of winter winter": Or("winter"),
+	"holodrum plain summer":  Or("summer"),
+	"spool swamp summer":     Or("summer"),
+	"spool swamp winter":     Or("winter"),
+	"sunken city spring":     Or("spring"),
+	"sunken city summer":     Or("summer"),
+	"sunken city winter":     Or("winter"),
+	"lost woods spring":      Or("spring"),
+	"lost woods summer":      Or("summer"),
+	"lost woods autumn":      Or("autumn"),
+	"lost woods winter":      Or("winter"),
+	"tarm ruins spring":      Or("spring"),
+	"tarm ruins winter":      Or("winter"),
+	"western coast summer":   Or("summer"),
+	"temple remains spring":  Or("spring"),
+	"temple remains summer":  Or("summer"),
+	"temple remains autumn":  Or("autumn"),
+	"temple remains winter":  Or("winter"),
+}
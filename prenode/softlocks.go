@@ -0,0 +1,61 @@
+package prenode
+
+// A SoftlockRule describes a way to get stuck: once all the Trigger nodes are
+// reached, the Target node must not be reachable without the Withhold nodes.
+// Otherwise you could get to the target before any of them, and have no way
+// out.
+//
+// If Slot is true, the target is an item slot, and the rule doesn't apply
+// until something is placed there, or if what's placed there is one of the
+// withheld nodes. The rule also doesn't apply if any withheld node is given,
+// like a starting item.
+//
+// If WithholdParentsOf is set, the parents of that node are withheld too, so
+// that a rule can share its list with the logic instead of copying it.
+type SoftlockRule struct {
+	Name              string
+	Trigger           []string
+	Target            string
+	Withhold          []string
+	WithholdParentsOf string
+	Slot              bool
+}
+
+// these are ordered, roughly, from least to most costly to check
+var softlockRules = []*SoftlockRule{
+	// you can't leave the shovel gift area without digging, so you need a
+	// shovel before getting there or from the gift itself
+	{
+		Name:     "shovel",
+		Trigger:  []string{"shovel gift"},
+		Target:   "shovel gift",
+		Withhold: []string{"shovel"},
+		Slot:     true,
+	},
+
+	// the spring banana cucco is surrounded by flowers, and you need a way to
+	// cut them that doesn't run out. you can still softlock if you forget to
+	// change season to spring, of course
+	{
+		Name:              "cucco",
+		Trigger:           []string{"spring banana cucco"},
+		Target:            "spring banana cucco",
+		WithholdParentsOf: "remove flower sustainable",
+	},
+
+	// if your feather is stolen in the hide & seek area and you can't dig it
+	// back up, you can't leave. you can't do hide & seek without jumping, so
+	// it doesn't matter if you can get there otherwise
+	{
+		Name:     "feather",
+		Trigger:  []string{"hide and seek", "jump"},
+		Target:   "hide and seek",
+		Withhold: []string{"shovel"},
+	},
+}
+
+// SoftlockRules returns the known softlock rules, in the order they should be
+// checked.
+func SoftlockRules() []*SoftlockRule {
+	return softlockRules
+}
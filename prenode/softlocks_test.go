@@ -0,0 +1,18 @@
+package prenode
+
+import "testing"
+
+// make sure every node the rules refer to exists, since a rule with a missing
+// node is skipped
+func TestSoftlockRuleNodes(t *testing.T) {
+	all := GetAll()
+	for _, rule := range SoftlockRules() {
+		names := append(append([]string{rule.Target}, rule.Trigger...),
+			rule.Withhold...)
+		for _, name := range names {
+			if all[name] == nil {
+				t.Errorf("%s rule: no such node: %s", rule.Name, name)
+			}
+		}
+	}
+}
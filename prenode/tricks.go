@@ -0,0 +1,82 @@
+package prenode
+
+// A Trick is a way to get somewhere that the logic doesn't expect by default,
+// because it's hard or relies on a glitch. Each entry in Alternatives is a
+// node and the parents of an And prenode that also satisfies it when the trick
+// is enabled.
+//
+// Level is the lowest difficulty that enables the trick; see Difficulties.
+type Trick struct {
+	Name         string
+	Level        string
+	Alternatives map[string][]string
+}
+
+// difficulties, from easiest to hardest. each one enables the tricks of the
+// ones before it too.
+var difficulties = []string{"casual", "hard", "glitched"}
+
+// Difficulties returns the names of the difficulty levels, from easiest to
+// hardest.
+func Difficulties() []string {
+	return difficulties
+}
+
+var tricks = []*Trick{
+	// most of the nodes marked "being nice" in dungeons.go
+	{
+		Name:         "agunima without feather",
+		Level:        "hard",
+		Alternatives: map[string][]string{"enter agunima": {"d4 pre-mid key"}},
+	},
+	{
+		Name:  "d5 boss key with L-1 feather",
+		Level: "hard",
+		Alternatives: map[string][]string{
+			"d5 boss key spot": {"d5 push ball", "d5 door D", "jump",
+				"sidescroll magnets"},
+		},
+	},
+	{
+		Name:  "d6 skipped key without feather",
+		Level: "hard",
+		Alternatives: map[string][]string{
+			"d6 skipped key chest": {"d6 spinner", "magnet gloves",
+				"break crystal"},
+		},
+	},
+	{
+		Name:  "d7 without killing",
+		Level: "hard",
+		Alternatives: map[string][]string{
+			"d7 pot room":     {"d7 armos room"},
+			"d7 armos puzzle": {"d7 pot room", "d7 fool's gap"},
+		},
+	},
+
+	// you can dodge mothula's attacks on foot if you're careful
+	{
+		Name:         "mothula without feather",
+		Level:        "hard",
+		Alternatives: map[string][]string{"kill mothula": {"damage mothula"}},
+	},
+
+	// running with pegasus seeds carries you over small gaps
+	{
+		Name:         "pegasus gaps",
+		Level:        "hard",
+		Alternatives: map[string][]string{"cross water gap": {"pegasus satchel"}},
+	},
+
+	// a bomb going off under you mid-jump makes the jump longer
+	{
+		Name:         "bomb jumps",
+		Level:        "glitched",
+		Alternatives: map[string][]string{"long jump": {"jump", "bombs"}},
+	},
+}
+
+// Tricks returns the known tricks, ordered by level.
+func Tricks() []*Trick {
+	return tricks
+}
@@ -0,0 +1,38 @@
+package prenode
+
+import "testing"
+
+// make sure every node the tricks refer to exists and can have alternatives,
+// since a trick with a missing node is skipped
+func TestTrickNodes(t *testing.T) {
+	all := GetAll()
+	seen := make(map[string]bool)
+	for _, trick := range Tricks() {
+		if seen[trick.Name] {
+			t.Errorf("duplicate trick: %s", trick.Name)
+		}
+		seen[trick.Name] = true
+
+		valid := false
+		for _, level := range Difficulties()[1:] {
+			valid = valid || trick.Level == level
+		}
+		if !valid {
+			t.Errorf("%s trick: bad level %q", trick.Name, trick.Level)
+		}
+
+		for name, parents := range trick.Alternatives {
+			if all[name] == nil {
+				t.Errorf("%s trick: no such node: %s", trick.Name, name)
+			} else if all[name].Type == RootType ||
+				all[name].Type == CountType {
+				t.Errorf("%s trick: can't add parents to %s", trick.Name, name)
+			}
+			for _, parent := range parents {
+				if all[parent] == nil {
+					t.Errorf("%s trick: no such node: %s", trick.Name, parent)
+				}
+			}
+		}
+	}
+}
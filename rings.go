@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jangler/oos-randomizer/prenode"
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+// the rings allowed in the pool by default, which are the ones that matter
+// for progression. other rings are just filler.
+var defaultRings = []string{
+	"expert's ring", "toss ring", "energy ring", "fist ring"}
+
+// returns an error if the list of rings can't be used: if a name isn't a
+// ring, if a ring is listed twice, or if there are more rings than there are
+// ring treasures to put them in.
+func checkRings(rings []string) error {
+	seen := make(map[string]bool, len(rings))
+	for _, name := range rings {
+		if !isRing(name) {
+			return fmt.Errorf("no such ring: %s", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate ring: %s", name)
+		}
+		seen[name] = true
+	}
+	_, err := rom.RingTreasures(rings)
+	return err
+}
+
+// returns true iff the name is the name of a ring
+func isRing(name string) bool {
+	for _, ring := range rom.Rings {
+		if name == ring {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true iff the name is the name of an item node for finding a ring,
+// like "find fist ring"
+func isRingItem(name string) bool {
+	return strings.HasPrefix(name, "find ") && isRing(name[len("find "):])
+}
+
+// returns the treasure to write for the named item node, given the ring
+// treasures for the seed from rom.RingTreasures. rings are looked up there,
+// since which ring treasure gives which ring changes from seed to seed.
+func seedTreasure(rings map[string]*rom.Treasure, name string) *rom.Treasure {
+	if t, ok := rings[name]; ok {
+		return t
+	}
+	return itemTreasure(name)
+}
+
+// returns the ring treasures as mutables for rom.Mutate, in a consistent
+// order.
+func ringMutables(rings map[string]*rom.Treasure) []rom.Mutable {
+	names := make([]string, 0, len(rings))
+	for name := range rings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mutables := make([]rom.Mutable, len(names))
+	for i, name := range names {
+		mutables[i] = rings[name]
+	}
+	return mutables
+}
+
+// replaces the rings in the item pool with the given ones. rings that already
+// have nodes (like "find fist ring") keep them, so they still count for
+// progression; the rest are filler.
+func applyRings(prenodes, items map[string]*prenode.Prenode, rings []string) {
+	for name := range items {
+		if isRingItem(name) {
+			delete(items, name)
+		}
+	}
+
+	for _, ring := range rings {
+		name := "find " + ring
+		if prenodes[name] == nil {
+			prenodes[name] = prenode.Root()
+		}
+		items[name] = prenodes[name]
+	}
+}
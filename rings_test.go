@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+func TestCheckRings(t *testing.T) {
+	if err := checkRings(defaultRings); err != nil {
+		t.Error(err)
+	}
+	if err := checkRings([]string{"power ring L-1", "fist ring", "toss ring",
+		"energy ring", "expert's ring"}); err == nil {
+		t.Error("no error for too many rings")
+	}
+	if err := checkRings([]string{"fake ring"}); err == nil {
+		t.Error("no error for unknown ring")
+	}
+	if err := checkRings([]string{"fist ring", "fist ring"}); err == nil {
+		t.Error("no error for duplicate ring")
+	}
+}
+
+func TestApplyRings(t *testing.T) {
+	prenodes, items := settingsPrenodes(&Settings{},
+		&Choices{Rings: []string{"fist ring", "red ring"}})
+
+	for _, name := range []string{"find fist ring", "find red ring"} {
+		if items[name] == nil {
+			t.Errorf("%s not in pool", name)
+		}
+	}
+	for _, name := range []string{"find toss ring", "find energy ring"} {
+		if items[name] != nil {
+			t.Errorf("%s still in pool", name)
+		}
+	}
+
+	// progression rings have to keep their nodes
+	if items["find fist ring"] != prenodes["find fist ring"] ||
+		prenodes["find punch ring"] == nil {
+		t.Error("fist ring lost its node")
+	}
+
+}
+
+func TestRingTreasures(t *testing.T) {
+	rings, err := rom.RingTreasures([]string{"fist ring", "red ring"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rings["find red ring"] == nil {
+		t.Error("no treasure for red ring")
+	}
+	if rom.Treasures["find red ring"] != nil ||
+		rom.Treasures["find toss ring"] == nil {
+		t.Error("global treasures changed")
+	}
+
+	// and the ROM reads back the same way
+	slots := map[string][]string{
+		"a": {"find expert's ring"},
+		"b": {"find toss ring"},
+		"c": {"find energy ring"},
+	}
+	renameRings(slots, rings)
+	for slot, want := range map[string]string{
+		"a": "find fist ring",
+		"b": "find red ring",
+		"c": "find energy ring",
+	} {
+		if slots[slot][0] != want {
+			t.Errorf("%s: want %s, got %s", slot, want, slots[slot][0])
+		}
+	}
+
+	if _, err := rom.RingTreasures(append(defaultRings,
+		"red ring")); err == nil {
+		t.Error("no error for too many rings")
+	}
+}
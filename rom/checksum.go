@@ -0,0 +1,57 @@
+package rom
+
+import "fmt"
+
+// cartridge header checksum locations
+const (
+	headerChecksumAddr = 0x14d
+	globalChecksumAddr = 0x14e // two bytes, big-endian
+)
+
+// the header checksum covers the title through the mask ROM version number
+func headerChecksum(b []byte) byte {
+	var x byte
+	for _, v := range b[titleAddr:headerChecksumAddr] {
+		x = x - v - 1
+	}
+	return x
+}
+
+// the global checksum is the sum of every byte in the ROM except its own
+func globalChecksum(b []byte) uint16 {
+	var x uint16
+	for i, v := range b {
+		if i != globalChecksumAddr && i != globalChecksumAddr+1 {
+			x += uint16(v)
+		}
+	}
+	return x
+}
+
+// recalculate both checksums so that the ROM is still a valid cartridge image
+// after being changed.
+func fixChecksums(b []byte) {
+	b[headerChecksumAddr] = headerChecksum(b)
+	sum := globalChecksum(b)
+	b[globalChecksumAddr] = byte(sum >> 8)
+	b[globalChecksumAddr+1] = byte(sum)
+}
+
+// returns an error for each checksum that doesn't match the ROM contents
+func checkChecksums(b []byte) []error {
+	errs := make([]error, 0)
+
+	if sum := headerChecksum(b); b[headerChecksumAddr] != sum {
+		errs = append(errs, fmt.Errorf(
+			"header checksum: expected %02x; found %02x",
+			sum, b[headerChecksumAddr]))
+	}
+	sum := globalChecksum(b)
+	found := uint16(b[globalChecksumAddr])<<8 | uint16(b[globalChecksumAddr+1])
+	if found != sum {
+		errs = append(errs, fmt.Errorf(
+			"global checksum: expected %04x; found %04x", sum, found))
+	}
+
+	return errs
+}
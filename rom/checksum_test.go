@@ -0,0 +1,18 @@
+package rom
+
+import "testing"
+
+func TestChecksums(t *testing.T) {
+	b := make([]byte, 0x8000)
+	copy(b[titleAddr:], "ZELDA DIN")
+	b[0x4000] = 0xff
+
+	if errs := checkChecksums(b); len(errs) != 2 {
+		t.Errorf("expected 2 checksum errors; got %v", errs)
+	}
+
+	fixChecksums(b)
+	if errs := checkChecksums(b); len(errs) != 0 {
+		t.Errorf("expected no checksum errors; got %v", errs)
+	}
+}
@@ -65,6 +65,13 @@ func (ms MutableSlot) Mutate(b []byte) error {
 	return ms.Treasure.Mutate(b)
 }
 
+// CanHold returns true iff the treasure works when placed in the slot.
+// Treasures without their own treasure data only work in chests, since their
+// collection mode can't be changed.
+func (ms MutableSlot) CanHold(t *Treasure) bool {
+	return t.addr != 0 || ms.CollectMode == CollectChest
+}
+
 // Check verifies that the slot's data matches the given ROM data.
 func (ms MutableSlot) Check(b []byte) error {
 	for _, addr := range ms.IDAddrs {
@@ -87,13 +94,54 @@ func (ms MutableSlot) Check(b []byte) error {
 	return nil
 }
 
+// Read returns the ID and sub ID at the slot's addresses in the given ROM
+// data. A slot without sub ID addresses always has its original treasure's sub
+// ID, since it can't be changed.
+func (ms MutableSlot) Read(b []byte) (id, subID byte, err error) {
+	if id, err = readSame(b, ms.IDAddrs, ms.Treasure.id); err != nil {
+		return 0, 0, err
+	}
+	if subID, err = readSame(b, ms.SubIDAddrs, ms.Treasure.subID); err != nil {
+		return 0, 0, err
+	}
+	return id, subID, nil
+}
+
+// returns the byte at all the given addresses, or def if there aren't any. it's
+// an error for the addresses to have different values.
+func readSame(b []byte, addrs []Addr, def byte) (byte, error) {
+	if len(addrs) == 0 {
+		return def, nil
+	}
+	value := b[addrs[0].FullOffset()]
+	for _, addr := range addrs[1:] {
+		if b[addr.FullOffset()] != value {
+			return 0, fmt.Errorf("expected %x at %x; found %x",
+				value, addr.FullOffset(), b[addr.FullOffset()])
+		}
+	}
+	return value, nil
+}
+
 var ItemSlots = map[string]*MutableSlot{
+	"d0 key chest": &MutableSlot{
+		Treasure:    Treasures["chest small key"],
+		IDAddrs:     []Addr{{0x15, 0x53f4}},
+		SubIDAddrs:  []Addr{{0x15, 0x53f5}},
+		CollectMode: CollectChest,
+	},
 	"d0 sword chest": &MutableSlot{
 		Treasure:    Treasures["sword L-1"],
 		IDAddrs:     []Addr{{0x15, 0x53fc}},
 		SubIDAddrs:  []Addr{{0x15, 0x53fd}},
 		CollectMode: CollectChest,
 	},
+	"d0 rupee chest": &MutableSlot{
+		Treasure:    Treasures["chest 30 rupees"],
+		IDAddrs:     []Addr{{0x15, 0x53f8}},
+		SubIDAddrs:  []Addr{{0x15, 0x53f9}},
+		CollectMode: CollectChest,
+	},
 	"maku key fall": &MutableSlot{
 		Treasure:    Treasures["gnarled key"],
 		IDAddrs:     []Addr{{0x15, 0x657d}, {0x09, 0x7dff}, {0x09, 0x7de6}},
@@ -119,12 +167,90 @@ var ItemSlots = map[string]*MutableSlot{
 		SubIDAddrs:  []Addr{{0x09, 0x669a}},
 		CollectMode: CollectFind2,
 	},
+	"d1 key chest": &MutableSlot{
+		Treasure:    Treasures["chest small key"],
+		IDAddrs:     []Addr{{0x15, 0x540c}},
+		SubIDAddrs:  []Addr{{0x15, 0x540d}},
+		CollectMode: CollectChest,
+	},
+	"d1 boss key chest": &MutableSlot{
+		Treasure:    Treasures["chest boss key"],
+		IDAddrs:     []Addr{{0x15, 0x5410}},
+		SubIDAddrs:  []Addr{{0x15, 0x5411}},
+		CollectMode: CollectChest,
+	},
+	"d1 map chest": &MutableSlot{
+		Treasure:    Treasures["chest map"],
+		IDAddrs:     []Addr{{0x15, 0x5418}},
+		SubIDAddrs:  []Addr{{0x15, 0x5419}},
+		CollectMode: CollectChest,
+	},
+	"d1 compass chest": &MutableSlot{
+		Treasure:    Treasures["chest compass"],
+		IDAddrs:     []Addr{{0x15, 0x5404}},
+		SubIDAddrs:  []Addr{{0x15, 0x5405}},
+		CollectMode: CollectChest,
+	},
+	"d1 gasha chest": &MutableSlot{
+		Treasure:    Treasures["chest gasha seed"],
+		IDAddrs:     []Addr{{0x15, 0x5400}},
+		SubIDAddrs:  []Addr{{0x15, 0x5401}},
+		CollectMode: CollectChest,
+	},
+	"d1 bomb chest": &MutableSlot{
+		Treasure:    Treasures["chest bombs"],
+		IDAddrs:     []Addr{{0x15, 0x5408}},
+		SubIDAddrs:  []Addr{{0x15, 0x5409}},
+		CollectMode: CollectChest,
+	},
 	"d2 bracelet chest": &MutableSlot{
 		Treasure:    Treasures["bracelet"],
 		IDAddrs:     []Addr{{0x15, 0x5424}},
 		SubIDAddrs:  []Addr{{0x15, 0x5425}},
 		CollectMode: CollectChest,
 	},
+	"d2 bomb key chest": &MutableSlot{
+		Treasure:    Treasures["chest small key"],
+		IDAddrs:     []Addr{{0x15, 0x542c}},
+		SubIDAddrs:  []Addr{{0x15, 0x542d}},
+		CollectMode: CollectChest,
+	},
+	"d2 blade key chest": &MutableSlot{
+		Treasure:    Treasures["chest small key"],
+		IDAddrs:     []Addr{{0x15, 0x5430}},
+		SubIDAddrs:  []Addr{{0x15, 0x5431}},
+		CollectMode: CollectChest,
+	},
+	"d2 boss key chest": &MutableSlot{
+		Treasure:    Treasures["chest boss key"],
+		IDAddrs:     []Addr{{0x15, 0x5420}},
+		SubIDAddrs:  []Addr{{0x15, 0x5421}},
+		CollectMode: CollectChest,
+	},
+	"d2 5-rupee chest": &MutableSlot{
+		Treasure:    Treasures["chest 5 rupees"],
+		IDAddrs:     []Addr{{0x15, 0x5438}},
+		SubIDAddrs:  []Addr{{0x15, 0x5439}},
+		CollectMode: CollectChest,
+	},
+	"d2 compass chest": &MutableSlot{
+		Treasure:    Treasures["chest compass"],
+		IDAddrs:     []Addr{{0x15, 0x5434}},
+		SubIDAddrs:  []Addr{{0x15, 0x5435}},
+		CollectMode: CollectChest,
+	},
+	"d2 map chest": &MutableSlot{
+		Treasure:    Treasures["chest map"],
+		IDAddrs:     []Addr{{0x15, 0x5428}},
+		SubIDAddrs:  []Addr{{0x15, 0x5429}},
+		CollectMode: CollectChest,
+	},
+	"d2 10-rupee chest": &MutableSlot{
+		Treasure:    Treasures["chest 10 rupees"],
+		IDAddrs:     []Addr{{0x15, 0x541c}},
+		SubIDAddrs:  []Addr{{0x15, 0x541d}},
+		CollectMode: CollectChest,
+	},
 	"blaino gift": &MutableSlot{
 		Treasure:    Treasures["ricky's gloves"],
 		IDAddrs:     []Addr{{0x0b, 0x64ce}},
@@ -0,0 +1,86 @@
+package rom
+
+import "fmt"
+
+// Rings lists the names of all the rings in the game, in index order.
+var Rings = [64]string{
+	"friendship ring", "power ring L-1", "power ring L-2", "power ring L-3",
+	"armor ring L-1", "armor ring L-2", "armor ring L-3", "red ring",
+	"blue ring", "green ring", "cursed ring", "expert's ring",
+	"blast ring", "rang ring L-1", "GBA time ring", "maple's ring",
+	"steadfast ring", "pegasus ring", "toss ring", "heart ring L-1",
+	"heart ring L-2", "swimmer's ring", "charge ring", "light ring L-1",
+	"light ring L-2", "bomber's ring", "green luck ring", "blue luck ring",
+	"gold luck ring", "red luck ring", "green holy ring", "blue holy ring",
+	"red holy ring", "snowshoe ring", "roc's ring", "quicksand ring",
+	"red joy ring", "blue joy ring", "gold joy ring", "green joy ring",
+	"discovery ring", "rang ring L-2", "octo ring", "moblin ring",
+	"like like ring", "subrosian ring", "first gen ring", "spin ring",
+	"bombproof ring", "energy ring", "dbl. edge ring", "GBA nature ring",
+	"slayer's ring", "rupee ring", "victory ring", "sign ring",
+	"100th ring", "whisp ring", "gasha ring", "peace ring",
+	"zora ring", "fist ring", "whimsical ring", "protection ring",
+}
+
+// ringIndex returns the index of the named ring, or -1 if there's no such
+// ring.
+func ringIndex(name string) int {
+	for i, ring := range Rings {
+		if ring == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// the ring treasures with params 4-7, the only ones that (normally) work. the
+// ring each one gives is set by its value byte.
+var ringTreasures = []*Treasure{
+	Treasures["find expert's ring"],
+	Treasures["find toss ring"],
+	Treasures["find energy ring"],
+	Treasures["find fist ring"],
+}
+
+// RingTreasures returns copies of the ring treasures set to give the named
+// rings, keyed as "find <ring name>". The Treasures map is left alone, since
+// which ring each treasure gives is different for every seed. Since there are
+// only four ring treasures, at most four rings can be used. If a ring is named
+// more than once, only the first treasure gives it.
+func RingTreasures(names []string) (map[string]*Treasure, error) {
+	if len(names) > len(ringTreasures) {
+		return nil, fmt.Errorf("can only use %d rings; got %d",
+			len(ringTreasures), len(names))
+	}
+
+	treasures := make(map[string]*Treasure, len(names))
+	for i, name := range names {
+		index := ringIndex(name)
+		if index < 0 {
+			return nil, fmt.Errorf("no such ring: %s", name)
+		}
+		if treasures["find "+name] != nil {
+			continue
+		}
+		t := *ringTreasures[i]
+		t.value = byte(index)
+		treasures["find "+name] = &t
+	}
+
+	return treasures, nil
+}
+
+// ReadRings returns the names of the rings that the ring treasures give in the
+// given ROM data, which are the rings given to RingTreasures when it was randomized.
+func ReadRings(b []byte) ([]string, error) {
+	names := make([]string, len(ringTreasures))
+	for i, t := range ringTreasures {
+		value := b[t.RealAddr()+1]
+		if int(value) >= len(Rings) {
+			return nil, fmt.Errorf("invalid ring index %02x at %x",
+				value, t.RealAddr()+1)
+		}
+		names[i] = Rings[value]
+	}
+	return names, nil
+}
@@ -1,11 +1,12 @@
 // Package rom deals with the structure of the OOS ROM file itself. The given
-// addresses are for the Japanese version of the game.
+// addresses are for the Japanese version of the game; see version.go.
 package rom
 
 import (
 	"crypto/sha1"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 )
 
@@ -27,16 +28,38 @@ func (a *Addr) FullOffset() int {
 	return bankOffset + int(a.Offset)
 }
 
-// Mutate changes the contents of loaded ROM bytes in place.
-func Mutate(b []byte) error {
+// Mutate changes the contents of loaded ROM bytes in place. Any extra mutables
+// (like the ring treasures for a seed) are applied after the package's own.
+func Mutate(b []byte, extra ...Mutable) error {
+	if err := checkVersion(b); err != nil {
+		return err
+	}
+
 	log.Printf("old bytes: sha-1 %x", sha1.Sum(b))
+	// apply mutables in a consistent order, so that the same input always
+	// results in the same output
+	keys := make([]string, 0, len(Mutables))
+	for k := range Mutables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	var err error
-	for _, m := range Mutables {
-		err = m.Mutate(b)
+	for _, k := range keys {
+		err = Mutables[k].Mutate(b)
 		if err != nil {
 			return err
 		}
 	}
+	for _, m := range extra {
+		if err := m.Mutate(b); err != nil {
+			return err
+		}
+	}
+
+	// this has to come last, since it depends on everything else
+	fixChecksums(b)
+
 	log.Printf("new bytes: sha-1 %x", sha1.Sum(b))
 	return nil
 }
@@ -44,6 +67,11 @@ func Mutate(b []byte) error {
 // Verify checks all the package's data against the ROM to see if it matches.
 // It returns a slice of errors describing each mismatch.
 func Verify(b []byte) []error {
+	// everything else would just be a wall of mismatches
+	if err := checkVersion(b); err != nil {
+		return []error{err}
+	}
+
 	errors := make([]error, 0)
 
 	for k, m := range Mutables {
@@ -54,9 +82,45 @@ func Verify(b []byte) []error {
 			errors = append(errors, fmt.Errorf("%s: %v", k, err))
 		}
 	}
+	errors = append(errors, checkChecksums(b)...)
 
 	if len(errors) > 0 {
 		return errors
 	}
 	return nil
 }
+
+// ReadSlots returns the names of the treasures in each item slot of the given
+// (possibly randomized) ROM data. Since some treasures share an ID and sub ID,
+// each slot maps to all the treasures it could be, in sorted order. Slots that
+// can't be read or don't hold a known treasure are left out, with an error
+// for each one.
+func ReadSlots(b []byte) (map[string][]string, []error) {
+	if err := checkVersion(b); err != nil {
+		return nil, []error{err}
+	}
+
+	slots := make(map[string][]string, len(ItemSlots))
+	errors := make([]error, 0)
+	for name, slot := range ItemSlots {
+		id, subID, err := slot.Read(b)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%s: %v", name, err))
+			continue
+		}
+		if treasures := FindTreasures(id, subID); len(treasures) > 0 {
+			slots[name] = treasures
+		} else {
+			errors = append(errors, fmt.Errorf(
+				"%s: unknown treasure %02x %02x", name, id, subID))
+		}
+	}
+
+	if len(errors) > 0 {
+		sort.Slice(errors, func(i, j int) bool {
+			return errors[i].Error() < errors[j].Error()
+		})
+		return slots, errors
+	}
+	return slots, nil
+}
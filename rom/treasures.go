@@ -3,6 +3,7 @@ package rom
 import (
 	"bytes"
 	"fmt"
+	"sort"
 )
 
 // collection modes
@@ -26,6 +27,11 @@ type Treasure struct {
 	mode, value, text, sprite byte
 }
 
+// ID returns item ID of the treasure.
+func (t Treasure) ID() byte {
+	return t.id
+}
+
 // SubID returns item sub ID of the treasure.
 func (t Treasure) SubID() byte {
 	return t.subID
@@ -44,6 +50,9 @@ func (t Treasure) Bytes() []byte {
 
 // Mutate replaces the associated treasure in the given ROM data with this one.
 func (t Treasure) Mutate(b []byte) error {
+	if t.addr == 0 {
+		return nil // no treasure data to change
+	}
 	addr, data := t.RealAddr(), t.Bytes()
 	for i := 0; i < 4; i++ {
 		b[addr+i] = data[i]
@@ -53,6 +62,9 @@ func (t Treasure) Mutate(b []byte) error {
 
 // Check verifies that the treasure's data matches the given ROM data.
 func (t Treasure) Check(b []byte) error {
+	if t.addr == 0 {
+		return nil
+	}
 	addr, data := t.RealAddr(), t.Bytes()
 	if bytes.Compare(b[addr:addr+4], data) != 0 {
 		return fmt.Errorf("expected %x at %x; found %x",
@@ -61,6 +73,31 @@ func (t Treasure) Check(b []byte) error {
 	return nil
 }
 
+// TreasureName returns the name of the given treasure in the Treasures map, or
+// an empty string if it isn't in the map.
+func TreasureName(t *Treasure) string {
+	for name, match := range Treasures {
+		if t == match {
+			return name
+		}
+	}
+	return ""
+}
+
+// FindTreasures returns the names of the treasures in the Treasures map with
+// the given ID and sub ID, in sorted order. Some treasures share both, so
+// there can be more than one.
+func FindTreasures(id, subID byte) []string {
+	names := make([]string, 0, 1)
+	for name, t := range Treasures {
+		if t.id == id && t.subID == subID {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Treasures maps item names to associated treasure data.
 var Treasures = map[string]*Treasure{
 	"shield L-1":    &Treasure{0x01, 0x00, 0x5701, 0x0a, 0x01, 0x1f, 0x13},
@@ -102,6 +139,20 @@ var Treasures = map[string]*Treasure{
 	"compass":   &Treasure{0x32, 0x00, 0x5869, 0x68, 0x00, 0x19, 0x41},
 	"map":       &Treasure{0x33, 0x00, 0x5875, 0x68, 0x00, 0x18, 0x40},
 
+	// these are the params that dungeon chests use for keys. their treasure
+	// data is left alone (addr 0), so they only work in other chests.
+	"chest small key": &Treasure{0x30, 0x03, 0x0000, 0x38, 0x00, 0x00, 0x00},
+	"chest boss key":  &Treasure{0x31, 0x03, 0x0000, 0x38, 0x00, 0x00, 0x00},
+
+	// same for the other things found in dungeon chests
+	"chest map":        &Treasure{0x33, 0x02, 0x0000, 0x38, 0x00, 0x00, 0x00},
+	"chest compass":    &Treasure{0x32, 0x02, 0x0000, 0x38, 0x00, 0x00, 0x00},
+	"chest gasha seed": &Treasure{0x34, 0x01, 0x0000, 0x38, 0x00, 0x00, 0x00},
+	"chest bombs":      &Treasure{0x03, 0x00, 0x0000, 0x38, 0x00, 0x00, 0x00},
+	"chest 5 rupees":   &Treasure{0x28, 0x01, 0x0000, 0x38, 0x00, 0x00, 0x00},
+	"chest 10 rupees":  &Treasure{0x28, 0x02, 0x0000, 0x38, 0x00, 0x00, 0x00},
+	"chest 30 rupees":  &Treasure{0x28, 0x04, 0x0000, 0x38, 0x00, 0x00, 0x00},
+
 	"gnarled key":     &Treasure{0x42, 0x00, 0x58a9, 0x29, 0x00, 0x42, 0x44},
 	"ricky's gloves":  &Treasure{0x48, 0x00, 0x568d, 0x09, 0x01, 0x67, 0x55},
 	"floodgate key":   &Treasure{0x43, 0x00, 0x5679, 0x09, 0x00, 0x43, 0x45},
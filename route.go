@@ -2,9 +2,11 @@ package main
 
 import (
 	"container/list"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
+	"sort"
 	"strings"
 
 	"github.com/jangler/oos-randomizer/graph"
@@ -16,13 +18,26 @@ import (
 type Route struct {
 	Graph graph.Graph
 	Slots map[string]*graph.Node
+	Items map[string]*graph.Node // the item pool
+
+	Pinned map[string]string // slot name -> item name, placed first
 }
 
 // NewRoute returns an initialized route with all prenodes, and those prenodes
 // with the names in start functioning as givens (always satisfied).
 func NewRoute(start []string) *Route {
+	return newRouteFromPrenodes(start, prenode.GetAll(), prenode.BaseItems())
+}
+
+// returns an initialized route with the given prenodes and item pool, which
+// may have been modified from the defaults. the maps are not modified.
+func newRouteFromPrenodes(start []string,
+	prenodes, items map[string]*prenode.Prenode) *Route {
 	g := graph.New()
-	totalPrenodes := prenode.GetAll()
+	totalPrenodes := make(map[string]*prenode.Prenode, len(prenodes))
+	for k, v := range prenodes {
+		totalPrenodes[k] = v
+	}
 
 	// make start nodes given
 	for _, key := range start {
@@ -40,7 +55,51 @@ func NewRoute(start []string) *Route {
 		}
 	}
 
-	return &Route{Graph: g, Slots: openSlots}
+	itemNodes := make(map[string]*graph.Node, len(items))
+	for name := range items {
+		itemNodes[name] = g[name]
+	}
+
+	return &Route{Graph: g, Slots: openSlots, Items: itemNodes}
+}
+
+// returns copies of the prenode map and item pool, modified according to the
+// settings and random choices.
+func settingsPrenodes(s *Settings,
+	c *Choices) (prenodes, items map[string]*prenode.Prenode) {
+	prenodes = prenode.GetAll()
+	items = make(map[string]*prenode.Prenode, len(prenode.BaseItems()))
+	for k, v := range prenode.BaseItems() {
+		items[k] = v
+	}
+
+	applyTricks(prenodes, enabledTricks(s.Difficulty, s.Tricks))
+	if s.Keysanity {
+		applyKeysanity(prenodes, items)
+	}
+	if s.Chests {
+		applyChests(prenodes, items)
+	}
+	if s.Essences > 0 && s.Essences < 8 {
+		applyEssences(prenodes, s.Essences)
+	}
+	if c.Rings != nil {
+		applyRings(prenodes, items, c.Rings)
+	}
+	if c.Seasons != nil {
+		applySeasons(prenodes, c.Seasons)
+	}
+	if s.StartItems != nil {
+		applyStartItems(items, s.StartItems)
+	}
+	padItemPool(prenodes, items)
+
+	return prenodes, items
+}
+
+// makes the maku seed require only n of the eight essences
+func applyEssences(prenodes map[string]*prenode.Prenode, n int) {
+	prenodes["maku seed"] = prenode.Count(n, prenodes["maku seed"].Parents...)
 }
 
 // CheckGraph returns an error for each orphan and childless node in the graph,
@@ -91,6 +150,10 @@ func addNodes(g graph.Graph, prenodes map[string]*prenode.Prenode) {
 			isStep := pt.Type == prenode.OrSlotType ||
 				pt.Type == prenode.OrStepType
 			g.AddNodes(graph.NewNode(key, graph.OrType, isStep))
+		case prenode.CountType:
+			node := graph.NewNode(key, graph.CountType, false)
+			node.Threshold = pt.Threshold
+			g.AddNodes(node)
 		default:
 			panic("unknown prenode type for " + key)
 		}
@@ -98,18 +161,52 @@ func addNodes(g graph.Graph, prenodes map[string]*prenode.Prenode) {
 }
 
 func addNodeParents(g graph.Graph, prenodes map[string]*prenode.Prenode) {
-	// ugly but w/e
-	for k, p := range prenodes {
-		g.AddParents(map[string][]string{k: p.Parents})
+	// ugly but w/e. the keys are sorted so that nodes' children are always in
+	// the same order, which keeps exploration deterministic.
+	for _, k := range sortedKeys(prenodes) {
+		g.AddParents(map[string][]string{k: prenodes[k].Parents})
+	}
+}
+
+// return the names of the route's slots in alphabetical order
+func sortedSlotNames(r *Route) []string {
+	return sortedNodeNames(r.Slots)
+}
+
+// return the keys of the given node map in alphabetical order
+func sortedNodeNames(nodes map[string]*graph.Node) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// return the keys of the given prenode map in alphabetical order
+func sortedKeys(prenodes map[string]*prenode.Prenode) []string {
+	keys := make([]string, 0, len(prenodes))
+	for key := range prenodes {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
 }
 
 // attempts to create a path to the given targets by placing different items in
-// slots.
-func findRoute(r *Route, start, goal, forbid []string,
-	maxlen int) (usedItems, usedSlots *list.List) {
+// slots. all randomness comes from src, so the same source state always yields
+// the same route.
+func findRoute(src *rand.Rand, r *Route, start, goal, forbid []string,
+	maxlen int) (usedItems, usedSlots *list.List, err error) {
+	if err := checkNodeNames(r.Graph, goal); err != nil {
+		return nil, nil, err
+	}
+	if err := checkNodeNames(r.Graph, forbid); err != nil {
+		return nil, nil, err
+	}
+
 	// make stacks out of the item names and slot names for backtracking
-	itemList, slotList := initRouteLists(r)
+	itemList, slotList := initRouteLists(src, r)
 
 	// also keep track of which items we've popped off the stacks.
 	// these lists are parallel; i.e. the first item is in the first slot
@@ -130,16 +227,26 @@ func findRoute(r *Route, start, goal, forbid []string,
 		forbidNodes[i] = r.Graph[name]
 	}
 
+	// pinned items and dungeon items are placed ahead of time, if there are
+	// any
+	if err := placePinnedItems(r, itemList, usedItems, slotList,
+		usedSlots); err != nil {
+		return nil, nil, err
+	}
+	if err := placeDungeonItems(r.Graph, startNodes,
+		itemList, usedItems, slotList, usedSlots); err != nil {
+		return nil, nil, err
+	}
+
 	// try to find the route
-	if tryExploreTargets(r.Graph, nil, startNodes, goalNodes,
+	if !tryExploreTargets(r.Graph, nil, startNodes, goalNodes,
 		forbidNodes, maxlen, itemList, usedItems, slotList, usedSlots) {
-		log.Print("-- success")
-		announceSuccessDetails(r, goal, usedItems, usedSlots)
-	} else {
-		log.Fatal("-- fatal: could not find route")
+		return nil, nil, errors.New("could not find route")
 	}
+	log.Print("-- success")
+	announceSuccessDetails(r, goal, usedItems, usedSlots)
 
-	return
+	return usedItems, usedSlots, nil
 }
 
 // try to reach all the given targets using the current graph status. if
@@ -221,20 +328,21 @@ func tryExploreTargets(g graph.Graph, start map[*graph.Node]bool,
 }
 
 // return shuffled lists of item and slot nodes
-func initRouteLists(r *Route) (itemList, slotList *list.List) {
-	// shuffle names in slices
-	items := make([]*graph.Node, 0, len(prenode.BaseItems()))
+func initRouteLists(src *rand.Rand, r *Route) (itemList, slotList *list.List) {
+	// shuffle names in slices. map iteration order is random, so sort the
+	// names first to make the shuffle depend only on src.
+	items := make([]*graph.Node, 0, len(r.Items))
 	slots := make([]*graph.Node, 0, len(r.Slots))
-	for itemName := range prenode.BaseItems() {
+	for _, itemName := range sortedNodeNames(r.Items) {
 		items = append(items, r.Graph[itemName])
 	}
-	for slotName := range r.Slots {
+	for _, slotName := range sortedNodeNames(r.Slots) {
 		slots = append(slots, r.Graph[slotName])
 	}
-	rand.Shuffle(len(items), func(i, j int) {
+	src.Shuffle(len(items), func(i, j int) {
 		items[i], items[j] = items[j], items[i]
 	})
-	rand.Shuffle(len(slots), func(i, j int) {
+	src.Shuffle(len(slots), func(i, j int) {
 		slots[i], slots[j] = slots[j], slots[i]
 	})
 
@@ -343,7 +451,13 @@ func shouldSkipItem(itemNode, slotNode *graph.Node,
 	// all, leaving it zeroed. so if we're looking at the star ore
 	// slot, then skip any items that have a nonzero sub ID.
 	if slotNode.Name == "star ore spot" &&
-		rom.Treasures[itemNode.Name].SubID() != 0 {
+		itemTreasure(itemNode.Name).SubID() != 0 {
+		skip = true
+	}
+	// dungeon items only work in their own dungeons, and some treasures only
+	// work in chests.
+	if isOutOfDungeon(itemNode.Name, slotNode.Name) ||
+		!rom.ItemSlots[slotNode.Name].CanHold(itemTreasure(itemNode.Name)) {
 		skip = true
 	}
 
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"container/list"
+	"math/rand"
 	"testing"
 
 	"github.com/jangler/oos-randomizer/graph"
@@ -22,3 +24,30 @@ func BenchmarkGraphExplore(b *testing.B) {
 			make(map[*graph.Node]bool), []*graph.Node{r.Graph[name]})
 	}
 }
+
+// make sure that the same seed always results in the same item and slot order
+func TestInitRouteListsSeed(t *testing.T) {
+	names := func(l *list.List) []string {
+		a := make([]string, 0, l.Len())
+		for e := l.Front(); e != nil; e = e.Next() {
+			a = append(a, e.Value.(*graph.Node).Name)
+		}
+		return a
+	}
+
+	for seed := int64(0); seed < 5; seed++ {
+		r1 := NewRoute([]string{"horon village"})
+		r2 := NewRoute([]string{"horon village"})
+		items1, slots1 := initRouteLists(rand.New(rand.NewSource(seed)), r1)
+		items2, slots2 := initRouteLists(rand.New(rand.NewSource(seed)), r2)
+
+		for _, pair := range [][2]*list.List{{items1, items2}, {slots1, slots2}} {
+			a, b := names(pair[0]), names(pair[1])
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("seed %d: want %v, got %v", seed, a, b)
+				}
+			}
+		}
+	}
+}
@@ -1,116 +1,138 @@
 package main
 
 import (
-	"errors"
+	"fmt"
 
 	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/prenode"
 )
 
-// TODO: write similar (?) functions to make sure dungeon navigation is
-//       possible w/ the locations of small keys and given item state
-
-// these are ordered, roughly, from least to most costly to check
-var softlockChecks = [](func(graph.Graph) error){
-	canShovelSoftlock,
-	canFlowerSoftlock,
-	canFeatherSoftlock,
-}
-
-// check for known softlock conditions
+// check for known softlock conditions. the rules are defined in the prenode
+// package, in softlocks.go, and small keys are checked last since they're the
+// most costly.
 func canSoftlock(g graph.Graph) error {
-	for _, check := range softlockChecks {
-		if err := check(g); err != nil {
+	for _, rule := range prenode.SoftlockRules() {
+		if err := checkSoftlockRule(g, rule); err != nil {
 			return err
 		}
 	}
-	return nil
+	return canKeySoftlock(g, nil)
 }
 
-// make sure you can't reach the shovel gift without either having a shovel
-// already or getting a shovel there, *if* the shovel gift has been assigned
-// yet.
-func canShovelSoftlock(g graph.Graph) error {
-	// first check if the gift has been reached
-	gift := g["shovel gift"]
-	if gift.Mark != graph.MarkTrue {
-		return nil
+// returns an error if the rule's target can be reached without any of its
+// withheld nodes, once its triggers have been reached. the graph's marks and
+// links are the same afterward as they were before.
+func checkSoftlockRule(g graph.Graph, rule *prenode.SoftlockRule) error {
+	// logic loaded with -logic-file might not have everything
+	names := append(append([]string{rule.Target}, rule.Trigger...),
+		rule.Withhold...)
+	if rule.WithholdParentsOf != "" {
+		names = append(names, rule.WithholdParentsOf)
 	}
-
-	shovel := g["shovel"]
-	parents := shovel.Parents
-
-	// if the slot hasn't been assigned yet or it *is* the shovel, it's fine
-	if len(gift.Children) > 0 &&
-		!graph.IsNodeInSlice(gift, shovel.Parents) {
-		// check whether gift is reachable if shovel is unreachable
-		shovel.ClearParents()
-		defer shovel.AddParents(parents...)
-		g.ClearMarks()
-		if gift.GetMark(gift, nil) == graph.MarkTrue {
-			return errors.New("shovel softlock")
+	for _, name := range names {
+		if g[name] == nil {
+			return nil
+		}
+	}
+	withholdNames := rule.Withhold
+	if rule.WithholdParentsOf != "" {
+		withholdNames = append([]string{}, withholdNames...)
+		for _, parent := range g[rule.WithholdParentsOf].Parents {
+			withholdNames = append(withholdNames, parent.Name)
 		}
 	}
 
-	return nil
-}
-
-// make sure you can't reach the spring banana cucco before having an item that
-// removes flowers. you can still softlock if you forget to change season to
-// spring, of course
-func canFlowerSoftlock(g graph.Graph) error {
-	// first check if cucco has been reached
-	cucco := g["spring banana cucco"]
-	if cucco.Mark != graph.MarkTrue {
-		return nil
+	// first check whether the rule applies at all
+	for _, name := range rule.Trigger {
+		if g[name].Mark != graph.MarkTrue {
+			return nil
+		}
+	}
+	target := g[rule.Target]
+	if rule.Slot && len(target.Children) == 0 {
+		return nil // nothing placed there yet
+	}
+	withheld := make([]*graph.Node, len(withholdNames))
+	for i, name := range withholdNames {
+		withheld[i] = g[name]
+		if isGiven(withheld[i]) {
+			return nil
+		}
+		if rule.Slot && graph.IsNodeInSlice(target, withheld[i].Parents) {
+			return nil // the slot gives you what you need
+		}
 	}
 
-	// temporarily make entrance and bush items unavailable
-	disabledNodes := append(g["remove flower sustainable"].Parents)
-	disabledParents := make([][]*graph.Node, len(disabledNodes))
-	for i, node := range disabledNodes {
-		disabledParents[i] = node.Parents
-		node.ClearParents()
+	// save the state of the graph, since checking changes the marks, and
+	// ClearParents reuses the parent slice
+	defer saveMarks(g)()
+	parents := make([][]*graph.Node, len(withheld))
+	for i, node := range withheld {
+		parents[i] = append([]*graph.Node{}, node.Parents...)
 	}
 	defer func() {
-		for i, node := range disabledNodes {
-			node.AddParents(disabledParents[i]...)
+		for i, node := range withheld {
+			node.ClearParents()
+			node.AddParents(parents[i]...)
 		}
 	}()
 
-	// see if you can still reach the exit
+	// see if the target can still be reached
+	for _, node := range withheld {
+		node.ClearParents()
+	}
 	g.ClearMarks()
-	if cucco.GetMark(cucco, nil) == graph.MarkTrue {
-		return errors.New("cucco softlock")
+	if target.GetMark(target, nil) == graph.MarkTrue {
+		return fmt.Errorf("%s softlock", rule.Name)
 	}
 	return nil
 }
 
-// make sure you can't reach the hide & seek area in subrosia without getting a
-// shovel first. if your feather is stolen and you can't dig it back up, you
-// can't exit that area.
-func canFeatherSoftlock(g graph.Graph) error {
-	// first check whether hide and seek has been reached
-	hideAndSeek := g["hide and seek"]
-	if hideAndSeek.Mark != graph.MarkTrue {
-		return nil
+// saves the marks of all the nodes in the graph, and returns a function that
+// restores them.
+func saveMarks(g graph.Graph) func() {
+	type nodeMark struct {
+		node *graph.Node
+		mark graph.Mark
 	}
-	// also test that you can jump, since you can't H&S without jumping (and it
-	// would be beneficial if you could)
-	if g["jump"].Mark != graph.MarkTrue {
-		return nil
+	marks := make([]nodeMark, 0, len(g))
+	for _, node := range g {
+		marks = append(marks, nodeMark{node, node.Mark})
 	}
+	return func() {
+		for _, nm := range marks {
+			nm.node.Mark = nm.mark
+		}
+	}
+}
 
-	shovel := g["shovel"]
-	parents := shovel.Parents
-
-	// check whether hide and seek is reachable if shovel is unreachable
+// returns true iff the node is always satisfied, like a starting item or
+// something that only depends on starting items. these can't be made
+// unavailable by clearing their parents.
+func isGiven(n *graph.Node) bool {
+	return isGivenVisited(n, make(map[*graph.Node]bool))
+}
 
-	shovel.ClearParents()
-	defer shovel.AddParents(parents...)
-	g.ClearMarks()
-	if hideAndSeek.GetMark(hideAndSeek, nil) == graph.MarkTrue {
-		return errors.New("feather softlock")
+func isGivenVisited(n *graph.Node, visited map[*graph.Node]bool) bool {
+	if visited[n] {
+		return false
 	}
-
-	return nil
+	visited[n] = true
+
+	switch n.Type {
+	case graph.AndType:
+		for _, parent := range n.Parents {
+			if !isGivenVisited(parent, visited) {
+				return false
+			}
+		}
+		return true
+	case graph.OrType:
+		for _, parent := range n.Parents {
+			if isGivenVisited(parent, visited) {
+				return true
+			}
+		}
+	}
+	return false
 }
@@ -8,6 +8,24 @@ import (
 	"github.com/jangler/oos-randomizer/prenode"
 )
 
+// returns a function that checks only the named softlock rule
+func softlockCheck(name string) func(graph.Graph) error {
+	for _, rule := range prenode.SoftlockRules() {
+		if rule.Name == name {
+			return func(g graph.Graph) error {
+				return checkSoftlockRule(g, rule)
+			}
+		}
+	}
+	panic("no such softlock rule: " + name)
+}
+
+var (
+	canShovelSoftlock  = softlockCheck("shovel")
+	canFlowerSoftlock  = softlockCheck("cucco")
+	canFeatherSoftlock = softlockCheck("feather")
+)
+
 func TestShovelLockCheck(t *testing.T) {
 	r := NewRoute([]string{"horon village"})
 	g := r.Graph
@@ -92,6 +110,47 @@ func TestFeatherLockCheck(t *testing.T) {
 	}
 }
 
+func TestSoftlockRuleRestoresGraph(t *testing.T) {
+	r := NewRoute([]string{"horon village"})
+	g := r.Graph
+
+	// same as the no-shovel case in TestShovelLockCheck, but with a shovel
+	// that comes too late
+	g["bracelet"].AddParents(g["d0 sword chest"])
+	g["feather L-1"].AddParents(g["maku key fall"])
+	g["sword L-1"].AddParents(g["shovel gift"])
+	g["flippers"].AddParents(g["blaino gift"])
+	g["shovel"].AddParents(g["boomerang gift"])
+	g["shovel gift"].GetMark(g["shovel gift"], nil)
+
+	marks := make(map[*graph.Node]graph.Mark, len(g))
+	for _, node := range g {
+		marks[node] = node.Mark
+	}
+	parents := append([]*graph.Node{}, g["shovel"].Parents...)
+
+	if canShovelSoftlock(g) == nil {
+		t.Error("false negative shovel softlock")
+	}
+	for node, mark := range marks {
+		if node.Mark != mark {
+			t.Errorf("mark of %s changed from %d to %d",
+				node.Name, mark, node.Mark)
+		}
+	}
+	if len(g["shovel"].Parents) != len(parents) {
+		t.Fatalf("shovel parents changed from %v to %v",
+			parents, g["shovel"].Parents)
+	}
+	for i, parent := range parents {
+		if g["shovel"].Parents[i] != parent ||
+			!graph.IsNodeInSlice(g["shovel"], parent.Children) {
+			t.Errorf("shovel parents changed from %v to %v",
+				parents, g["shovel"].Parents)
+		}
+	}
+}
+
 // helper function used for the other benchmarks
 func benchGraphCheck(b *testing.B, check func(graph.Graph) error) {
 	// make a list of base item nodes to use for testing
@@ -145,3 +204,26 @@ func BenchmarkCanFlowerSoftlock(b *testing.B) {
 func BenchmarkCanFeatherSoftlock(b *testing.B) {
 	benchGraphCheck(b, canFeatherSoftlock)
 }
+
+func TestStartItemLockChecks(t *testing.T) {
+	r := NewRoute([]string{"horon village", "shovel", "sword L-1"})
+	g := r.Graph
+	if !isGiven(g["shovel"]) || !isGiven(g["sword"]) {
+		t.Fatal("starting items not given")
+	}
+	if isGiven(g["feather L-1"]) {
+		t.Fatal("unslotted item given")
+	}
+
+	// starting with a shovel means the shovel gift is safe, even if it has
+	// something else in it, and starting with a sword means flowers are too
+	g["bracelet"].AddParents(g["shovel gift"])
+	g["shovel gift"].Mark = graph.MarkTrue
+	g["spring banana cucco"].Mark = graph.MarkTrue
+	if err := canShovelSoftlock(g); err != nil {
+		t.Error(err)
+	}
+	if err := canFlowerSoftlock(g); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+var seasonNames = []string{"spring", "summer", "autumn", "winter"}
+
+// overworld areas that have a default season, and the nodes that mean you're
+// in them. if you're in an area, its default season is available without the
+// rod.
+var seasonAreas = map[string]string{
+	"north horon":     "eyeglass lake",
+	"eastern suburbs": "sokra stump",
+	"woods of winter": "post-d2 stump",
+	"holodrum plain":  "ghastly stump",
+	"spool swamp":     "spool swamp",
+	"sunken city":     "mount cucco",
+	"lost woods":      "tarm ruins",
+	"tarm ruins":      "tarm gale tree",
+	"western coast":   "pirate ship",
+	"temple remains":  "temple remains",
+}
+
+// returns the names of the season areas in alphabetical order
+func sortedAreas() []string {
+	areas := make([]string, 0, len(seasonAreas))
+	for area := range seasonAreas {
+		areas = append(areas, area)
+	}
+	sort.Strings(areas)
+	return areas
+}
+
+// returns a random default season for each area
+func chooseSeasons(src *rand.Rand) map[string]string {
+	seasons := make(map[string]string, len(seasonAreas))
+	for _, area := range sortedAreas() {
+		seasons[area] = seasonNames[src.Intn(len(seasonNames))]
+	}
+	return seasons
+}
+
+// rebuilds each area's season nodes from its chosen default season: the
+// default is available in the area without the rod, and the other seasons
+// need the rod, whatever the nodes were before. area seasons that the logic
+// doesn't care about are left alone.
+func applySeasons(prenodes map[string]*prenode.Prenode,
+	seasons map[string]string) {
+	for _, area := range sortedAreas() {
+		for _, season := range seasonNames {
+			name := area + " " + season
+			if prenodes[name] == nil {
+				continue
+			}
+			if seasons[area] == season {
+				prenodes[name] = prenode.Or(season, seasonAreas[area])
+			} else {
+				prenodes[name] = prenode.Or(season)
+			}
+		}
+	}
+}
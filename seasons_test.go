@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+func TestSeasonAreas(t *testing.T) {
+	prenodes := prenode.GetAll()
+	for area, node := range seasonAreas {
+		if prenodes[node] == nil {
+			t.Errorf("no node %s for %s", node, area)
+		}
+	}
+}
+
+func TestApplySeasons(t *testing.T) {
+	seasons := chooseSeasons(rand.New(rand.NewSource(0)))
+	if len(seasons) != len(seasonAreas) {
+		t.Fatalf("want %d seasons, got %d", len(seasonAreas), len(seasons))
+	}
+
+	// swamp starts in summer, so d3 doesn't need the rod
+	seasons["spool swamp"] = "summer"
+	prenodes, _ := settingsPrenodes(&Settings{}, &Choices{Seasons: seasons})
+	parents := prenodes["spool swamp summer"].Parents
+	if len(parents) != 2 || parents[1] != "spool swamp" {
+		t.Errorf("spool swamp summer has parents %v", parents)
+	}
+	if parents := prenodes["spool swamp winter"].Parents; len(parents) != 1 {
+		t.Errorf("spool swamp winter has parents %v", parents)
+	}
+
+	// and changing the default again takes away the old one
+	seasons["spool swamp"] = "winter"
+	applySeasons(prenodes, seasons)
+	if parents := prenodes["spool swamp summer"].Parents; len(parents) != 1 {
+		t.Errorf("spool swamp summer has parents %v", parents)
+	}
+	if parents := prenodes["spool swamp winter"].Parents; len(parents) != 2 {
+		t.Errorf("spool swamp winter has parents %v", parents)
+	}
+
+	// and the base logic is untouched
+	base := prenode.GetAll()["spool swamp summer"]
+	if len(base.Parents) != 1 {
+		t.Errorf("base spool swamp summer has parents %v", base.Parents)
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"regexp"
+	"sort"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+// matches the names of locked door nodes, which count the keys in their
+// dungeon
+var doorRegexp = regexp.MustCompile(`^d\d door [A-Z]$`)
+
+// any small key opens any locked door in its dungeon, and the logic counts
+// keys so that no order of opening doors should leave a player without a key
+// for a door they need. this makes sure of it by checking every order in which
+// a dungeon's doors could be opened, and returns an error if any of them
+// leaves something out of reach that the logic says is reachable.
+//
+// this relies on the marks from exploring, like the other softlock checks.
+// assumed is the items that were explored from along with the roots, when
+// checking placements before everything has been placed.
+func canKeySoftlock(g graph.Graph, assumed []*graph.Node) error {
+	baseline := make(map[*graph.Node]bool)
+	roots := append([]*graph.Node{}, assumed...)
+	keys := make(map[string][]*graph.Node)
+	doors := make(map[string][]*graph.Node)
+	for name, node := range g {
+		if node.Mark == graph.MarkTrue {
+			baseline[node] = true
+		}
+		if node.Type == graph.AndType && len(node.Parents) == 0 {
+			roots = append(roots, node)
+		}
+		if smallKeyRegexp.MatchString(name) {
+			keys[name[:2]] = append(keys[name[:2]], node)
+		} else if doorRegexp.MatchString(name) {
+			doors[name[:2]] = append(doors[name[:2]], node)
+		}
+	}
+
+	// sort everything so that exploration happens in the same order
+	sortNodes(roots)
+	dungeons := make([]string, 0, len(doors))
+	for dungeon := range doors {
+		dungeons = append(dungeons, dungeon)
+	}
+	sort.Strings(dungeons)
+
+	for _, dungeon := range dungeons {
+		// with only one door, there's nowhere else to use a key
+		if len(doors[dungeon]) < 2 {
+			continue
+		}
+		sortNodes(keys[dungeon])
+		sortNodes(doors[dungeon])
+		if err := checkDungeonKeys(g, roots, baseline,
+			keys[dungeon], doors[dungeon]); err != nil {
+			return fmt.Errorf("%s %v", dungeon, err)
+		}
+	}
+	return nil
+}
+
+// simulates opening the given doors in every order, using the given keys and
+// starting from the roots, and returns an error if any order can't reach
+// everything in the baseline set. the graph's marks and links are the same
+// afterward as they were before.
+func checkDungeonKeys(g graph.Graph, roots []*graph.Node,
+	baseline map[*graph.Node]bool, keys, doors []*graph.Node) error {
+	// nothing to spend yet
+	found := false
+	for _, key := range keys {
+		if baseline[key] {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	// cut the doors off from the keys. a door node being reached means that
+	// it's been opened.
+	defer saveMarks(g)()
+	locked := graph.NewNode("locked door", graph.OrType, false)
+	sources := make([][]*graph.Node, len(doors))
+	for i, door := range doors {
+		sources[i] = append([]*graph.Node{}, door.Parents...)
+		door.ClearParents()
+		door.AddParents(locked)
+	}
+	defer func() {
+		for i, door := range doors {
+			door.ClearParents()
+			door.AddParents(sources[i]...)
+		}
+	}()
+
+	seen := make(map[uint]bool)
+	var visit func(opened uint, reached map[*graph.Node]bool) error
+	visit = func(opened uint, reached map[*graph.Node]bool) error {
+		if seen[opened] {
+			return nil
+		}
+		seen[opened] = true
+
+		// count the keys found so far, and the doors that could be opened
+		// with them
+		available := countReached(keys, reached, nil) - bits.OnesCount(opened)
+		atHand := make([]int, 0)
+		for i, door := range doors {
+			if opened&(1<<uint(i)) == 0 && isDoorAtHand(door, reached) {
+				atHand = append(atHand, i)
+			}
+		}
+
+		// no more doors can be opened, so this is as far as this order goes
+		if available <= 0 || len(atHand) == 0 {
+			for node := range baseline {
+				// doors are locked here, so they don't count
+				if !reached[node] && nodeIndex(doors, node) < 0 {
+					return fmt.Errorf("key softlock: %s", node.Name)
+				}
+			}
+			return nil
+		}
+
+		// even with enough keys for every door at hand, opening one can lead
+		// to another door that takes the key meant for the rest, so each one
+		// is tried in turn
+		for _, i := range atHand {
+			if err := visit(opened|1<<uint(i), g.Explore(reached,
+				[]*graph.Node{doors[i]})); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return visit(0, g.Explore(make(map[*graph.Node]bool), roots))
+}
+
+// returns true iff something behind the door isn't reached yet, but would be
+// if the door were open.
+func isDoorAtHand(door *graph.Node, reached map[*graph.Node]bool) bool {
+	for _, child := range door.Children {
+		if !reached[child] && isSatisfied(child, child.Parents, reached, door) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns true iff the node would be satisfied by the reached set if it had
+// the given parents, counting extra as reached if it's not nil.
+func isSatisfied(node *graph.Node, parents []*graph.Node,
+	reached map[*graph.Node]bool, extra *graph.Node) bool {
+	switch node.Type {
+	case graph.AndType:
+		return countReached(parents, reached, extra) == len(parents)
+	case graph.OrType:
+		return countReached(parents, reached, extra) > 0
+	case graph.CountType:
+		return countReached(parents, reached, extra) >= node.Threshold
+	}
+	return false
+}
+
+// returns the number of the nodes that are in the reached set, counting extra
+// as reached if it's not nil.
+func countReached(nodes []*graph.Node, reached map[*graph.Node]bool,
+	extra *graph.Node) int {
+	count := 0
+	for _, node := range nodes {
+		if reached[node] || node == extra {
+			count++
+		}
+	}
+	return count
+}
+
+// returns the index of the node in the slice, or -1 if it's not there
+func nodeIndex(nodes []*graph.Node, node *graph.Node) int {
+	for i, match := range nodes {
+		if match == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// sorts the nodes by name
+func sortNodes(nodes []*graph.Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+)
+
+// returns a tiny dungeon from the given links, with a door for each of the
+// named rooms that counts all the keys. doors only need one key, so that using
+// keys in the wrong order can leave something out of reach.
+func makeKeyDungeon(keys, rooms []string,
+	links map[string][]string) graph.Graph {
+	g := graph.New()
+	g.AddNodes(graph.NewNode("enter d9", graph.AndType, false))
+	for _, key := range keys {
+		g.AddNodes(graph.NewNode(key, graph.AndType, false))
+	}
+	for _, room := range rooms {
+		g.AddNodes(graph.NewNode(room, graph.AndType, false))
+	}
+	for door := range links {
+		if doorRegexp.MatchString(door) {
+			node := graph.NewNode(door, graph.CountType, false)
+			node.Threshold = 1
+			g.AddNodes(node)
+			links[door] = keys
+		}
+	}
+	g.AddParents(links)
+	g.Explore(make(map[*graph.Node]bool), []*graph.Node{g["enter d9"]})
+	return g
+}
+
+func TestKeyLockCheck(t *testing.T) {
+	// two doors next to the entrance, where the second key is behind the
+	// first door. using the first key on the second door leaves you stuck.
+	keys, rooms := []string{"d9 key A", "d9 key B"},
+		[]string{"d9 room A", "d9 room B"}
+	links := map[string][]string{
+		"d9 key A":  {"enter d9"},
+		"d9 room A": {"enter d9", "d9 door A"},
+		"d9 key B":  {"d9 room A"},
+		"d9 room B": {"enter d9", "d9 door B"},
+		"d9 door A": nil,
+		"d9 door B": nil,
+	}
+	g := makeKeyDungeon(keys, rooms, links)
+	if canKeySoftlock(g, nil) == nil {
+		t.Error("key softlock not detected")
+	}
+
+	// and the graph is the same afterward
+	for _, node := range g {
+		if node.Mark != graph.MarkTrue {
+			t.Errorf("%s mark not restored", node.Name)
+		}
+	}
+	if len(g["d9 door B"].Parents) != 2 ||
+		g["d9 door B"].Parents[1] != g["d9 key B"] {
+		t.Errorf("d9 door B parents not restored: %v", g["d9 door B"].Parents)
+	}
+
+	// but not if the second door can't be reached first
+	links["d9 room B"] = []string{"d9 room A", "d9 door B"}
+	if err := canKeySoftlock(makeKeyDungeon(keys, rooms, links),
+		nil); err != nil {
+		t.Error(err)
+	}
+
+	// or if there are enough keys for the doors at hand, but opening one of
+	// them leads to another door that takes the key for the other
+	keys, rooms = []string{"d9 key A", "d9 key B", "d9 key C"},
+		[]string{"d9 room A", "d9 room B", "d9 room C"}
+	links = map[string][]string{
+		"d9 key A":  {"enter d9"},
+		"d9 key B":  {"enter d9"},
+		"d9 key C":  {"d9 room B"},
+		"d9 room A": {"enter d9", "d9 door A"},
+		"d9 room B": {"enter d9", "d9 door B"},
+		"d9 room C": {"d9 room A", "d9 door C"},
+		"d9 door A": nil,
+		"d9 door B": nil,
+		"d9 door C": nil,
+	}
+	if canKeySoftlock(makeKeyDungeon(keys, rooms, links), nil) == nil {
+		t.Error("key softlock behind a door not detected")
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jangler/oos-randomizer/rom"
+)
+
+// A Spoiler is a record of how a ROM was randomized, meant to be archived and
+// checked by people other than the randomizer.
+type Spoiler struct {
+	Seed     string            `json:"seed"`
+	Settings *Settings         `json:"settings"`
+	Choices  *Choices          `json:"choices"`
+	Slots    map[string]string `json:"slots"` // slot name -> treasure name
+
+	Playthrough []Sphere `json:"playthrough"`
+}
+
+// newSpoiler returns a spoiler for the given settings, choices, playthrough,
+// and the current state of rom.ItemSlots. It should be called after
+// randomization.
+// placed maps slot names to the names of the item nodes placed in them, which
+// are used instead of treasure names where given.
+func newSpoiler(s *Settings, c *Choices, placed map[string]string,
+	playthrough []Sphere) *Spoiler {
+	slots := make(map[string]string, len(rom.ItemSlots))
+	for name, slot := range rom.ItemSlots {
+		if item, ok := placed[name]; ok {
+			slots[name] = item
+		} else {
+			slots[name] = rom.TreasureName(slot.Treasure)
+		}
+	}
+
+	return &Spoiler{
+		Seed:     fmt.Sprintf("%08x", s.Seed),
+		Settings: s,
+		Choices:  c,
+		Slots:    slots,
+
+		Playthrough: playthrough,
+	}
+}
+
+// writeSpoiler writes the spoiler to the named file, as plain text if the
+// filename ends in ".txt" and as JSON otherwise.
+func writeSpoiler(filename string, sp *Spoiler) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(filename, ".txt") {
+		return sp.writeText(f)
+	}
+	return sp.writeJSON(f)
+}
+
+// writeJSON writes the spoiler as indented JSON. Map keys are sorted, so the
+// output is the same for the same spoiler.
+func (sp *Spoiler) writeJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(sp, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// writeText writes the spoiler in a plain, human-readable format.
+func (sp *Spoiler) writeText(w io.Writer) error {
+	lines := []string{
+		"seed: " + sp.Seed,
+		"goal: " + strings.Join(sp.Settings.Goal, ", "),
+		"forbid: " + strings.Join(sp.Settings.Forbid, ", "),
+		fmt.Sprintf("maxlen: %d", sp.Settings.MaxLen),
+		fmt.Sprintf("keysanity: %v", sp.Settings.Keysanity),
+		fmt.Sprintf("chests: %v", sp.Settings.Chests),
+		fmt.Sprintf("essences: %d", sp.Settings.Essences),
+		"start items: " + strings.Join(sp.Settings.StartItems, ", "),
+		"difficulty: " + sp.Settings.Difficulty,
+		"tricks: " + strings.Join(enabledTricks(sp.Settings.Difficulty,
+			sp.Settings.Tricks), ", "),
+		"rings: " + strings.Join(sp.Choices.Rings, ", "),
+	}
+
+	if sp.Choices.Seasons != nil {
+		lines = append(lines, "", "default seasons:")
+		for _, area := range sortedAreas() {
+			lines = append(lines,
+				fmt.Sprintf("%s: %s", area, sp.Choices.Seasons[area]))
+		}
+	}
+
+	lines = append(lines, "", "slots:")
+
+	slotNames := make([]string, 0, len(sp.Slots))
+	for name := range sp.Slots {
+		slotNames = append(slotNames, name)
+	}
+	sort.Strings(slotNames)
+	for _, name := range slotNames {
+		lines = append(lines, fmt.Sprintf("%s <- %s", sp.Slots[name], name))
+	}
+
+	lines = append(lines, "", "playthrough:")
+	for i, sphere := range sp.Playthrough {
+		lines = append(lines, fmt.Sprintf("sphere %d:", i))
+		for _, p := range sphere {
+			lines = append(lines, "\t"+p.String())
+		}
+	}
+
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
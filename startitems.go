@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+// if items are taken out of the pool (by -start-items, for example), it's
+// padded with bombchus. they're numbered since each item needs its own node.
+var bombchusRegexp = regexp.MustCompile(`^bombchus \d+$`)
+
+// returns an error if any of the named items can't be a starting item
+func checkStartItems(names []string) error {
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if prenode.BaseItems()[name] == nil {
+			return fmt.Errorf("no such starting item: %s", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate starting item: %s", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// takes the starting items out of the pool. they still need to be given in
+// the start nodes of the route.
+func applyStartItems(items map[string]*prenode.Prenode, names []string) {
+	for _, name := range names {
+		delete(items, name)
+	}
+}
+
+// adds filler items to the pool until it has at least as many items to spare
+// as the default pool does. the backtracking algorithm needs some slack, since
+// items that don't lead anywhere can only be placed after the goal is reached.
+func padItemPool(prenodes, items map[string]*prenode.Prenode) {
+	spare := len(prenode.BaseItems()) - countSlots(prenode.GetAll())
+	slots := countSlots(prenodes)
+
+	for i := 1; len(items) < slots+spare; i++ {
+		name := fmt.Sprintf("bombchus %d", i)
+		prenodes[name] = prenode.Root()
+		items[name] = prenodes[name]
+	}
+}
+
+// returns the number of slot prenodes in the map
+func countSlots(prenodes map[string]*prenode.Prenode) int {
+	n := 0
+	for _, p := range prenodes {
+		if p.Type == prenode.AndSlotType || p.Type == prenode.OrSlotType {
+			n++
+		}
+	}
+	return n
+}
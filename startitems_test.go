@@ -0,0 +1,53 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+func TestStartItems(t *testing.T) {
+	if err := checkStartItems([]string{"feather L-1", "satchel"}); err != nil {
+		t.Error(err)
+	}
+	if err := checkStartItems([]string{"feather L-3"}); err == nil {
+		t.Error("no error for unknown item")
+	}
+	if err := checkStartItems([]string{"satchel", "satchel"}); err == nil {
+		t.Error("no error for duplicate item")
+	}
+
+	// take out enough items that the pool needs padding. the sword is needed
+	// here so that the spring banana cucco isn't a softlock from the start.
+	s := &Settings{StartItems: []string{"feather L-1", "satchel", "shovel",
+		"bracelet", "flippers", "sword L-1", "gnarled key"}}
+	prenodes, items := settingsPrenodes(s, &Choices{})
+	for _, name := range s.StartItems {
+		if items[name] != nil {
+			t.Errorf("%s still in pool", name)
+		}
+	}
+
+	start := append([]string{"horon village"}, s.StartItems...)
+	r := newRouteFromPrenodes(start, prenodes, items)
+	if len(r.Items) != len(prenode.BaseItems()) {
+		t.Errorf("want %d items in pool, got %d",
+			len(prenode.BaseItems()), len(r.Items))
+	}
+	if len(r.Items) < len(r.Slots) {
+		t.Fatalf("%d items for %d slots", len(r.Items), len(r.Slots))
+	}
+	if itemTreasure("bombchus 1") == nil {
+		t.Error("no treasure for filler bombchus")
+	}
+
+	_, usedSlots, err := findRoute(rand.New(rand.NewSource(0)), r, start,
+		[]string{"done"}, []string{}, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usedSlots.Len() != len(r.Slots) {
+		t.Errorf("want %d slots filled, got %d", len(r.Slots), usedSlots.Len())
+	}
+}
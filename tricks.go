@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+// returns an error if the difficulty isn't a known one, or if any of the
+// toggles isn't a known trick. see enabledTricks.
+func checkTricks(difficulty string, toggles []string) error {
+	if difficultyLevel(difficulty) < 0 {
+		return fmt.Errorf("no such difficulty: %s", difficulty)
+	}
+	for _, toggle := range toggles {
+		if findTrick(strings.TrimPrefix(toggle, "-")) == nil {
+			return fmt.Errorf("no such trick: %s", toggle)
+		}
+	}
+	return nil
+}
+
+// returns the names of the tricks enabled by the difficulty and toggles, in
+// the order they're defined. the difficulty enables tricks at or below its
+// level, and then each toggle turns a trick on, or off if it starts with "-".
+// an empty difficulty is the same as casual.
+func enabledTricks(difficulty string, toggles []string) []string {
+	level := difficultyLevel(difficulty)
+	enabled := make(map[string]bool)
+	for _, trick := range prenode.Tricks() {
+		enabled[trick.Name] = difficultyLevel(trick.Level) <= level
+	}
+	for _, toggle := range toggles {
+		if strings.HasPrefix(toggle, "-") {
+			enabled[toggle[1:]] = false
+		} else {
+			enabled[toggle] = true
+		}
+	}
+
+	names := make([]string, 0)
+	for _, trick := range prenode.Tricks() {
+		if enabled[trick.Name] {
+			names = append(names, trick.Name)
+		}
+	}
+	return names
+}
+
+// returns the index of the difficulty in prenode.Difficulties, or -1 if
+// there's no such difficulty.
+func difficultyLevel(difficulty string) int {
+	if difficulty == "" {
+		return 0
+	}
+	for i, name := range prenode.Difficulties() {
+		if name == difficulty {
+			return i
+		}
+	}
+	return -1
+}
+
+// returns the trick with the given name, or nil if there isn't one
+func findTrick(name string) *prenode.Trick {
+	for _, trick := range prenode.Tricks() {
+		if trick.Name == name {
+			return trick
+		}
+	}
+	return nil
+}
+
+// adds the alternatives for the named tricks to the prenodes. an And prenode
+// that gets an alternative is split into an Or of its original parents
+// ("enter agunima (normal)") and the trick's ("enter agunima (agunima without
+// feather)"), keeping its slot or step type.
+func applyTricks(prenodes map[string]*prenode.Prenode, names []string) {
+	for _, trickName := range names {
+		trick := findTrick(trickName)
+
+		// sort for consistent order
+		nodeNames := make([]string, 0, len(trick.Alternatives))
+		for name := range trick.Alternatives {
+			nodeNames = append(nodeNames, name)
+		}
+		sort.Strings(nodeNames)
+
+		for _, name := range nodeNames {
+			addAlternative(prenodes, name, trickName, trick.Alternatives[name])
+		}
+	}
+}
+
+// gives the named prenode an alternative And prenode with the given parents.
+// nothing is changed if any of the nodes don't exist, since logic loaded with
+// -logic-file might not have everything.
+func addAlternative(prenodes map[string]*prenode.Prenode, name,
+	trickName string, parents []string) {
+	pn := prenodes[name]
+	if pn == nil {
+		return
+	}
+	for _, parent := range parents {
+		if prenodes[parent] == nil {
+			return
+		}
+	}
+
+	altName := fmt.Sprintf("%s (%s)", name, trickName)
+	normalName := name + " (normal)"
+	switch pn.Type {
+	case prenode.OrType, prenode.OrSlotType, prenode.OrStepType:
+		prenodes[name] = &prenode.Prenode{
+			Parents: append(append([]string{}, pn.Parents...), altName),
+			Type:    pn.Type,
+		}
+	case prenode.AndType:
+		prenodes[name] = prenode.Or(normalName, altName)
+	case prenode.AndSlotType:
+		prenodes[name] = prenode.OrSlot(normalName, altName)
+	case prenode.AndStepType:
+		prenodes[name] = prenode.OrStep(normalName, altName)
+	default:
+		return // roots and counts can't have alternatives
+	}
+
+	if pn.Type != prenodes[name].Type {
+		prenodes[normalName] = prenode.And(pn.Parents...)
+	}
+	prenodes[altName] = prenode.And(parents...)
+}
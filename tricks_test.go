@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/jangler/oos-randomizer/graph"
+	"github.com/jangler/oos-randomizer/prenode"
+)
+
+func TestEnabledTricks(t *testing.T) {
+	if err := checkTricks("hard", []string{"bomb jumps",
+		"-pegasus gaps"}); err != nil {
+		t.Error(err)
+	}
+	if err := checkTricks("expert", nil); err == nil {
+		t.Error("no error for unknown difficulty")
+	}
+	if err := checkTricks("", []string{"-wall clips"}); err == nil {
+		t.Error("no error for unknown trick")
+	}
+
+	if tricks := enabledTricks("", nil); len(tricks) != 0 {
+		t.Errorf("casual tricks enabled by default: %v", tricks)
+	}
+	if tricks := enabledTricks("glitched",
+		nil); len(tricks) != len(prenode.Tricks()) {
+		t.Errorf("glitched doesn't enable every trick: %v", tricks)
+	}
+	want := []string{"mothula without feather", "bomb jumps"}
+	if tricks := enabledTricks("casual", []string{"bomb jumps",
+		"mothula without feather"}); !reflect.DeepEqual(tricks, want) {
+		t.Errorf("want %v, got %v", want, tricks)
+	}
+	for _, name := range enabledTricks("hard", []string{"-pegasus gaps"}) {
+		if name == "pegasus gaps" || name == "bomb jumps" {
+			t.Errorf("%s enabled", name)
+		}
+	}
+}
+
+func TestTricks(t *testing.T) {
+	start := []string{"horon village"}
+	s := &Settings{Difficulty: "glitched"}
+	prenodes, items := settingsPrenodes(s, &Choices{})
+
+	// and nodes are split, and or nodes get another parent
+	if pn := prenodes["enter agunima"]; pn.Type != prenode.OrType ||
+		!reflect.DeepEqual(pn.Parents, []string{"enter agunima (normal)",
+			"enter agunima (agunima without feather)"}) {
+		t.Errorf("bad enter agunima prenode: %+v", pn)
+	}
+	if pn := prenodes["long jump"]; pn.Type != prenode.OrType ||
+		pn.Parents[len(pn.Parents)-1] != "long jump (bomb jumps)" {
+		t.Errorf("bad long jump prenode: %+v", pn)
+	}
+
+	// and the tricks work
+	r := newRouteFromPrenodes(start, prenodes, items)
+	g := r.Graph
+	for _, name := range []string{"feather L-1", "rupees"} {
+		g[name].AddParents(g["horon village"])
+	}
+	reached := g.Explore(make(map[*graph.Node]bool),
+		[]*graph.Node{g["horon village"]})
+	if !reached[g["long jump"]] {
+		t.Error("no long jump with feather L-1 and bombs")
+	}
+
+	prenodes, items = settingsPrenodes(s, &Choices{})
+	r = newRouteFromPrenodes(start, prenodes, items)
+	if _, _, err := findRoute(rand.New(rand.NewSource(0)), r, start,
+		[]string{"done"}, []string{}, -1); err != nil {
+		t.Fatal(err)
+	}
+}